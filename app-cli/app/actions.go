@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/tucats/gopackages/app-cli/cli"
@@ -41,9 +42,14 @@ func OutputFormatAction(c *cli.Context) error {
 	return nil
 }
 
+// LanguageAction sets the language used for messages from the --language
+// option, which is a BCP 47 language tag such as "pt-BR" or a locale name such
+// as "pt_BR.UTF-8". This replaces the language from the environment.
 func LanguageAction(c *cli.Context) error {
 	if language, ok := c.FindGlobal().String("language"); ok {
-		i18n.Language = strings.ToLower(language)[0:2]
+		if !i18n.SetLanguage(language) {
+			return errors.ErrInvalidLanguage.Context(language)
+		}
 	}
 
 	return nil
@@ -51,24 +57,191 @@ func LanguageAction(c *cli.Context) error {
 
 // LogAction is an action routine to set the loggers that will get debug messages
 // during execution. This must be a string list, and each named logger is enabled.
-// If a logger name is not valid, an error is returned.
+// A logger name can be followed by a colon and a log level, such as "server:debug".
+// If a logger name or level is not valid, an error is returned.
 func LogAction(c *cli.Context) error {
 	loggers, specified := c.FindGlobal().StringList("log")
 
 	if specified {
 		for _, v := range loggers {
-			name := strings.TrimSpace(v)
-			if name != "" {
-				logger := ui.LoggerByName(name)
-				if logger < 0 {
-					return errors.ErrInvalidLoggerName.Context(name)
-				}
-
-				ui.Active(logger, true)
+			if err := enableLogger(v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LogFormatAction is an action routine to set the format of log messages.
+func LogFormatAction(c *cli.Context) error {
+	if format, specified := c.FindGlobal().String("log-format"); specified {
+		return ui.SetLogFormat(format)
+	}
+
+	return nil
+}
+
+// LogSinkAction is an action routine to add destinations for log messages. Each
+// item in the list is "stdout", "stderr", "file=path", "syslog", "syslog=socket",
+// "memory", or "memory=size". The kind of sink can be followed by a colon and a
+// list of logger names, such as "stderr:rest,server" or "file:sql=sql.log", in
+// which case the sink only receives messages from those loggers. When there is
+// no log file and no destination is given, log messages are written to stderr,
+// so they are not mixed with the command's output.
+func LogSinkAction(c *cli.Context) error {
+	specs, specified := c.FindGlobal().StringList("log-sink")
+
+	if specified {
+		for _, spec := range joinLogSinkSpecs(specs) {
+			if err := addLogSink(c.FindGlobal().AppName, spec); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// logSinkKinds are the kinds of log sinks that can be given in a sink
+// specification.
+var logSinkKinds = []string{"stdout", "stderr", "file", "syslog", "memory"}
+
+// joinLogSinkSpecs rejoins the logger names of sink specifications that were
+// split at their commas when the option value was read as a list. An item is
+// part of the previous specification if that specification has a list of
+// logger names but no argument yet, and the item starts with a logger name
+// rather than a kind of sink.
+func joinLogSinkSpecs(items []string) []string {
+	specs := make([]string, 0, len(items))
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		name, _, _ := strings.Cut(item, "=")
+
+		if n := len(specs) - 1; n >= 0 && !util.InList(strings.ToLower(name), logSinkKinds...) && ui.LoggerByName(name) >= 0 {
+			kind, _, hasArgument := strings.Cut(specs[n], "=")
+			if !hasArgument && strings.Contains(kind, ":") {
+				specs[n] = specs[n] + "," + item
+
+				continue
+			}
+		}
+
+		specs = append(specs, item)
+	}
+
+	return specs
+}
+
+// parseLogSink parses a sink specification into the kind of sink, its
+// argument, and the loggers whose messages it receives. There are no
+// loggers if the sink receives messages from all of them.
+func parseLogSink(spec string) (string, string, []int, error) {
+	kind, argument, _ := strings.Cut(strings.TrimSpace(spec), "=")
+	kind, names, hasNames := strings.Cut(kind, ":")
+
+	var classes []int
+
+	if hasNames {
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+
+			class := ui.LoggerByName(name)
+			if class < 0 {
+				return "", "", nil, errors.ErrInvalidLoggerName.Context(name)
+			}
+
+			classes = append(classes, class)
+		}
+	}
+
+	return strings.ToLower(strings.TrimSpace(kind)), argument, classes, nil
+}
+
+// addLogSink adds the log sink described by a sink specification. The sink is
+// named using the specification. An empty specification is ignored.
+func addLogSink(appName, spec string) error {
+	spec = strings.TrimSpace(spec)
+
+	kind, argument, classes, err := parseLogSink(spec)
+	if err != nil {
+		return err
+	}
+
+	var sink ui.LogSink
+
+	switch kind {
+	case "":
+		return nil
+
+	case "stdout":
+		sink = ui.NewStreamSink(os.Stdout)
+
+	case "stderr":
+		sink = ui.NewStreamSink(os.Stderr)
+
+	case "file":
+		if argument == "" {
+			return errors.ErrInvalidLogSink.Context(spec)
+		}
+
+		sink, err = ui.NewFileSink(argument)
+
+	case "syslog":
+		sink, err = ui.NewSyslogSink(argument, appName)
+
+	case "memory":
+		size := 0
+
+		if argument != "" {
+			if size, err = strconv.Atoi(argument); err != nil || size < 1 {
+				return errors.ErrInvalidLogSink.Context(spec)
 			}
 		}
+
+		sink = ui.NewRingSink(size)
+
+	default:
+		return errors.ErrInvalidLogSink.Context(spec)
 	}
 
+	if err != nil {
+		return errors.NewError(err).Context(spec)
+	}
+
+	ui.AddLogSink(spec, sink, classes...)
+
+	return nil
+}
+
+// enableLogger enables the logger named in a logger specification, which is a
+// logger name optionally followed by a colon and the log level for the logger.
+// An empty specification is ignored.
+func enableLogger(spec string) error {
+	name, levelName, hasLevel := strings.Cut(strings.TrimSpace(spec), ":")
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+
+	logger := ui.LoggerByName(name)
+	if logger < 0 {
+		return errors.ErrInvalidLoggerName.Context(name)
+	}
+
+	if hasLevel {
+		level := ui.LevelByName(strings.TrimSpace(levelName))
+		if level < 0 {
+			return errors.ErrInvalidLogLevel.Context(levelName)
+		}
+
+		ui.SetLevel(logger, level)
+	}
+
+	ui.Active(logger, true)
+
 	return nil
 }
 
@@ -77,6 +250,10 @@ func LogFileAction(c *cli.Context) error {
 	logFile, specified := c.FindGlobal().String("log-file")
 
 	if specified {
+		if err := setLogRotation(); err != nil {
+			return err
+		}
+
 		return ui.OpenLogFile(logFile, false)
 	}
 
@@ -90,6 +267,23 @@ func QuietAction(c *cli.Context) error {
 	return nil
 }
 
+// DebugAction is an action routine that makes errors record the Go call
+// stack where they are created, so an error that ends the application is
+// reported in full.
+func DebugAction(c *cli.Context) error {
+	errors.CaptureStacks(c.FindGlobal().Boolean("debug"))
+
+	return nil
+}
+
+// setErrorStacks applies the profile setting that makes errors record the
+// Go call stack where they are created.
+func setErrorStacks() {
+	if settings.Get(defs.ErrorStackSetting) != "" {
+		errors.CaptureStacks(settings.GetBool(defs.ErrorStackSetting))
+	}
+}
+
 func VersionAction(c *cli.Context) error {
 	arch := fmt.Sprintf("%s, %s", runtime.GOOS, runtime.GOARCH)
 	if arch == "darwin, arm64" {
@@ -6,15 +6,16 @@ package app
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"strings"
 
 	"github.com/tucats/gopackages/app-cli/cli"
 	"github.com/tucats/gopackages/app-cli/settings"
-	"github.com/tucats/gopackages/app-cli/ui"
 	"github.com/tucats/gopackages/defs"
 	"github.com/tucats/gopackages/errors"
 	"github.com/tucats/gopackages/expressions/symbols"
+	"github.com/tucats/gopackages/i18n"
 )
 
 // App is the wrapper type for information needed for a command line application.
@@ -49,6 +50,11 @@ type App struct {
 	// be overridden in the grammar definition for the command line options and
 	// subcommands.
 	Action func(c *cli.Context) error
+
+	// This is an optional file system, such as an embed.FS, and the directory
+	// in it that contains the message catalogs of the application.
+	catalogs         fs.FS
+	catalogDirectory string
 }
 
 // New creates a new instance of an application object, given the name of the
@@ -122,6 +128,14 @@ func (app *App) SetDefaultAction(f func(c *cli.Context) error) *App {
 // Run runs a grammar given a set of arguments in the current
 // applciation. The grammar must declare action routines for the
 // various subcommands, which will be executed by the parser.
+//
+// If the command fails because of an error that cli.MapExitCode() maps
+// to an exit code, such as cli.ExitAuthError if the user is not logged
+// on, the error is a cli.ExitError with that exit status that wraps the
+// error that caused it, so errors.Is() and errors.As() still find it.
+// Calling its Exit() method reports the error, in full if the --debug
+// option was used, and exits the program. Any other error is returned
+// unchanged.
 func (app *App) Run(grammar []cli.Option, args []string) error {
 	app.Context = &cli.Context{
 		Description: app.Description,
@@ -133,10 +147,34 @@ func (app *App) Run(grammar []cli.Option, args []string) error {
 		Action:      app.Action,
 	}
 
+	if err := app.run(); err != nil {
+		if exitError, ok := err.(cli.ExitError); ok {
+			return exitError
+		}
+
+		if status := cli.ExitStatus(err); status != cli.ExitGeneralError {
+			return cli.ExitErrorFor(err, status)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// run sets up the default loggers and message catalogs, and then parses
+// the command line and runs the actions.
+func (app *App) run() error {
 	if err := SetDefaultLoggers(); err != nil {
 		return err
 	}
 
+	if app.catalogs != nil {
+		if err := i18n.LoadCatalogs(app.catalogs, app.catalogDirectory); err != nil {
+			return errors.ErrInvalidCatalog.Context(err.Error())
+		}
+	}
+
 	return runFromContext(app.Context)
 }
 
@@ -151,14 +189,8 @@ func SetDefaultLoggers() error {
 	loggers := strings.Split(logList, ",")
 
 	for _, loggerName := range loggers {
-		trimmedName := strings.TrimSpace(loggerName)
-		if trimmedName != "" {
-			logger := ui.LoggerByName(trimmedName)
-			if logger < 0 {
-				return errors.ErrInvalidLoggerName.Context(trimmedName)
-			}
-
-			ui.Active(logger, true)
+		if err := enableLogger(loggerName); err != nil {
+			return err
 		}
 	}
 
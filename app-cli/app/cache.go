@@ -0,0 +1,31 @@
+package app
+
+import (
+	"github.com/tucats/gopackages/app-cli/cli"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/i18n"
+	"github.com/tucats/gopackages/rest"
+)
+
+// CacheGrammar describes the cache subcommands.
+var CacheGrammar = []cli.Option{
+	{
+		LongName:    "clear",
+		OptionType:  cli.Subcommand,
+		Description: "app.cache.clear",
+		Action:      CacheClearAction,
+	},
+}
+
+// CacheClearAction removes all the cached REST responses from the cache
+// directory in the profile directory.
+func CacheClearAction(c *cli.Context) error {
+	count, err := rest.ClearCache()
+	if err == nil {
+		ui.Say("%s", i18n.M("cache.cleared", map[string]interface{}{
+			"count": count,
+		}))
+	}
+
+	return err
+}
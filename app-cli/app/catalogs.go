@@ -0,0 +1,48 @@
+package app
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/i18n"
+)
+
+// The name of the directory in the profile directory that holds message
+// catalogs, unless the profile says otherwise.
+const catalogDirectory = "i18n"
+
+// SetCatalogs sets a directory of message catalog files, such as one in an
+// embed.FS, that localizes the application. The catalogs are loaded before
+// the command line is parsed, so they can localize the help text. Catalogs
+// in the profile's catalog directory are loaded after these, and replace any
+// of the same messages. See i18n.LoadCatalogs for the file formats.
+func (app *App) SetCatalogs(fsys fs.FS, dir string) *App {
+	app.catalogs = fsys
+	app.catalogDirectory = dir
+
+	return app
+}
+
+// loadCatalogs loads the message catalogs from the directory named in the
+// profile, or from the "i18n" directory in the profile directory.
+func loadCatalogs() error {
+	path := settings.Get(defs.CatalogPathSetting)
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+
+		path = filepath.Join(home, settings.ProfileDirectory, catalogDirectory)
+	}
+
+	if err := i18n.LoadCatalogDirectory(path); err != nil {
+		return errors.ErrInvalidCatalog.Context(err.Error())
+	}
+
+	return nil
+}
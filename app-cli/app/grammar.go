@@ -33,6 +33,27 @@ var applicationGrammar = []cli.Option{
 		Action:      Logon,
 		Value:       LogonGrammar,
 	},
+	{
+		LongName:    "logout",
+		Aliases:     []string{"logoff"},
+		OptionType:  cli.Subcommand,
+		Description: "app.logout",
+		Action:      Logout,
+		Value:       LogoutGrammar,
+	},
+	{
+		LongName:    "cache",
+		OptionType:  cli.Subcommand,
+		Description: "app.cache",
+		Value:       CacheGrammar,
+	},
+	{
+		LongName:    "log",
+		OptionType:  cli.Subcommand,
+		Description: "app.log",
+		Action:      LogQueryAction,
+		Value:       LogGrammar,
+	},
 	{
 		ShortName:           "p",
 		LongName:            "profile",
@@ -56,6 +77,28 @@ var applicationGrammar = []cli.Option{
 		Action:              LogFileAction,
 		EnvironmentVariable: defs.DefaultLogFileName,
 	},
+	{
+		LongName:            "log-format",
+		Description:         "global.log.format",
+		OptionType:          cli.KeywordType,
+		Keywords:            []string{ui.TextFormat, ui.JSONFormat},
+		Action:              LogFormatAction,
+		EnvironmentVariable: defs.DefaultLogFormat,
+	},
+	{
+		LongName:            "log-sink",
+		Description:         "global.log.sink",
+		OptionType:          cli.StringListType,
+		Action:              LogSinkAction,
+		EnvironmentVariable: defs.DefaultLogSinks,
+	},
+	{
+		LongName:    "language",
+		Aliases:     []string{"lang"},
+		Description: "global.language",
+		OptionType:  cli.StringType,
+		Action:      LanguageAction,
+	},
 	{
 		LongName:            "format",
 		ShortName:           "f",
@@ -65,6 +108,13 @@ var applicationGrammar = []cli.Option{
 		Action:              OutputFormatAction,
 		EnvironmentVariable: "APP_OUTPUT_FORMAT",
 	},
+	{
+		LongName:            "debug",
+		Description:         "global.debug",
+		OptionType:          cli.BooleanType,
+		Action:              DebugAction,
+		EnvironmentVariable: "APP_DEBUG",
+	},
 	{
 		ShortName:   "v",
 		LongName:    "version",
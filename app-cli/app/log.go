@@ -0,0 +1,171 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/cli"
+	"github.com/tucats/gopackages/app-cli/tables"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/i18n"
+)
+
+// The time formats accepted by the --since and --until options, in addition
+// to durations such as "2h", which are relative to the current time.
+var logTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// LogGrammar describes the options of the log command.
+var LogGrammar = []cli.Option{
+	{
+		LongName:    "file",
+		OptionType:  cli.StringType,
+		Description: "log.file",
+	},
+	{
+		LongName:    "class",
+		Aliases:     []string{"logger"},
+		OptionType:  cli.StringListType,
+		Description: "log.class",
+	},
+	{
+		LongName:    "since",
+		OptionType:  cli.StringType,
+		Description: "log.since",
+	},
+	{
+		LongName:    "until",
+		OptionType:  cli.StringType,
+		Description: "log.until",
+	},
+	{
+		LongName:    "first",
+		OptionType:  cli.IntType,
+		Description: "log.first",
+	},
+	{
+		LongName:    "last",
+		OptionType:  cli.IntType,
+		Description: "log.last",
+	},
+	{
+		LongName:    "session",
+		OptionType:  cli.IntType,
+		Description: "log.session",
+	},
+	{
+		LongName:    "match",
+		OptionType:  cli.StringType,
+		Description: "log.match",
+	},
+	{
+		LongName:    "limit",
+		OptionType:  cli.IntType,
+		Description: "log.limit",
+	},
+}
+
+// LogQueryAction shows the messages from the log file, and its rolled over
+// versions, that match the command options.
+func LogQueryAction(c *cli.Context) error {
+	query := ui.LogQuery{}
+
+	query.File, _ = c.String("file")
+	query.Classes, _ = c.StringList("class")
+	query.FirstSequence, _ = c.Integer("first")
+	query.LastSequence, _ = c.Integer("last")
+	query.Session, _ = c.Integer("session")
+	query.Pattern, _ = c.String("match")
+	query.Limit, _ = c.Integer("limit")
+
+	for _, name := range query.Classes {
+		if ui.LoggerByName(name) == ui.NoSuchLogger {
+			return errors.ErrInvalidLoggerName.Context(name)
+		}
+	}
+
+	var err error
+
+	if text, found := c.String("since"); found {
+		if query.Since, err = parseLogTime(text); err != nil {
+			return err
+		}
+	}
+
+	if text, found := c.String("until"); found {
+		if query.Until, err = parseLogTime(text); err != nil {
+			return err
+		}
+	}
+
+	records, err := ui.QueryLog(query)
+	if err != nil {
+		return err
+	}
+
+	switch ui.OutputFormat {
+	case ui.JSONFormat:
+		b, _ := json.Marshal(records)
+		fmt.Println(string(b))
+
+	case ui.JSONIndentedFormat:
+		b, _ := json.MarshalIndent(records, "", "  ")
+		fmt.Println(string(b))
+
+	default:
+		t, _ := tables.New([]string{
+			i18n.L("Time"),
+			i18n.L("Sequence"),
+			i18n.L("Logger"),
+			i18n.L("Session"),
+			i18n.L("Message"),
+		})
+
+		for _, record := range records {
+			session := ""
+			if record.Session > 0 {
+				session = fmt.Sprintf("%d", record.Session)
+			}
+
+			_ = t.AddRowItems(
+				i18n.FormatDateTime(record.Time),
+				record.Sequence,
+				record.Class,
+				session,
+				strings.ReplaceAll(record.Message, "\n", " "))
+		}
+
+		t.SetPagination(0, 0)
+		t.ShowUnderlines(false)
+
+		return t.Print(ui.TextFormat)
+	}
+
+	return nil
+}
+
+// parseLogTime converts the value of a --since or --until option to a time.
+// A duration, such as "90m", is subtracted from the current time.
+func parseLogTime(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+
+	if age, err := parseAge(text); err == nil {
+		return time.Now().Add(-age), nil
+	}
+
+	for _, format := range logTimeFormats {
+		if t, err := time.ParseInLocation(format, text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.ErrInvalidValue.Context(text)
+}
@@ -1,7 +1,6 @@
 package app
 
 import (
-	"encoding/json"
 	"net/http"
 	"net/url"
 	"strings"
@@ -13,7 +12,6 @@ import (
 	"github.com/tucats/gopackages/errors"
 	"github.com/tucats/gopackages/i18n"
 	"github.com/tucats/gopackages/rest"
-	"gopkg.in/resty.v1"
 )
 
 // LogonGrammar describes the login subcommand options.
@@ -30,7 +28,14 @@ var LogonGrammar = []cli.Option{
 		ShortName:           "p",
 		OptionType:          cli.StringType,
 		Description:         "password",
-		EnvironmentVariable: "APP_PASSWORD",
+		EnvironmentVariable: defs.DefaultPassword,
+	},
+	{
+		LongName:            "method",
+		ShortName:           "m",
+		OptionType:          cli.StringType,
+		Description:         "logon.method",
+		EnvironmentVariable: "APP_LOGON_METHOD",
 	},
 	{
 		LongName:            "logon-server",
@@ -40,17 +45,26 @@ var LogonGrammar = []cli.Option{
 		Description:         "logon.server",
 		EnvironmentVariable: "APP_LOGON_SERVER",
 	},
+	{
+		LongName:    "status",
+		OptionType:  cli.Subcommand,
+		Description: "app.logon.status",
+		Action:      LogonStatus,
+	},
 }
 
-// Logon handles the logon subcommand. This accepts a username and
-// password string from the user via the command line, or console
-// input if not provided on the command line. These credentials are
-// used to connect to an Ego logon server and request an authentication
-// token to be used for subsequent operations.
+// Logon handles the logon subcommand. This uses the authentication method
+// selected by the --method option or the profile to get a credential for
+// the logon server. For the default Ego token method, this accepts a username
+// and password string from the user via the command line, or console input
+// if not provided on the command line. These credentials are used to connect
+// to an Ego logon server and request an authentication token to be used for
+// subsequent operations.
 //
 // If the user credentials are valid and a token is returned, it is
 // stored in the user's active profile where it can be accessed by
-// other Ego commands as needed.
+// other Ego commands as needed. Tokens are stored for each server, so
+// the user can be logged on to more than one server at a time.
 func Logon(c *cli.Context) error {
 	var err error
 
@@ -74,110 +88,56 @@ func Logon(c *cli.Context) error {
 		ui.Log(ui.RestLogger, "Logon URL is %s", url)
 	}
 
-	// Get the username. If not supplied by the user, prompt until provided.
-	user, _ := c.String("username")
-	for user == "" {
-		user = ui.Prompt("Username: ")
+	// Find the authentication method to use. If it was given on the command
+	// line, it becomes the method stored in the profile.
+	method := rest.AuthenticationMethod()
+	if c.WasFound("method") {
+		method, _ = c.String("method")
+		method = strings.ToLower(method)
 	}
 
-	// Get the password. If not supplied by the user, prompt until provided.
-	pass, _ := c.String("password")
-	for pass == "" {
-		pass = ui.PromptPassword(i18n.L("password.prompt"))
-	}
-
-	// Turn logon server address and endpoint into full URL.
-	url = strings.TrimSuffix(url, "/") + defs.ServicesLogonPath
-
-	// Create a new client, set it's attribute for basic authentication, and
-	// generate a request. The request is made using the logon agent info.
-	// Finall, call the endpoint.
-	restClient := resty.New().SetDisableWarn(true)
-
-	if tlsConf, err := rest.GetTLSConfiguration(); err != nil {
+	authenticator, err := rest.GetAuthenticator(method)
+	if err != nil {
 		return err
-	} else {
-		restClient.SetTLSClientConfig(tlsConf)
 	}
 
-	req := restClient.NewRequest()
-	req.Body = defs.Credentials{Username: user, Password: pass}
-
-	if ui.IsActive(ui.RestLogger) {
-		// Use a fake password payload for the REST logging so we don't expose the password
-		b, _ := json.MarshalIndent(defs.Credentials{Username: user, Password: "********"}, "", "  ")
-		ui.Log(ui.RestLogger, "REST Request:\n%s", string(b))
+	if c.WasFound("method") {
+		settings.Set(defs.LogonAuthenticationSetting, method)
 	}
 
-	req.Header.Set("Accept", defs.JSONMediaType)
-	rest.AddAgent(req, defs.LogonAgent)
+	// Get the username and password, if given. The authenticator will prompt
+	// for any values it needs that were not supplied.
+	user, _ := c.String("username")
+	pass, _ := c.String("password")
 
-	r, err := req.Post(url)
+	credential, err := authenticator.Logon(url, user, pass)
 	if err != nil {
-		ui.Log(ui.RestLogger, "REST POST %s; failed %v", url, err)
-
 		return errors.NewError(err)
 	}
 
-	ui.Log(ui.RestLogger, "REST POST %s; status %d", url, r.StatusCode())
-
-	// If the call was successful and the server responded with Success, remove any trailing
-	// newline from the result body and store the string as the new token value.
-	if err == nil && r.StatusCode() == http.StatusOK {
-		payload := defs.LogonResponse{}
-
-		err := json.Unmarshal(r.Body(), &payload)
-		if err != nil {
-			return errors.NewError(err).In("logon")
-		}
-
-		if ui.IsActive(ui.RestLogger) {
-			b, _ := json.MarshalIndent(payload, "", "  ")
-			ui.Log(ui.RestLogger, "REST Response:\n%s", string(b))
-		}
-
-		settings.Set(defs.LogonTokenSetting, payload.Token)
-		settings.Set(defs.LogonTokenExpirationSetting, payload.Expiration)
-
-		err = settings.Save()
-		if err == nil {
-			msg := i18n.M("logged.in", map[string]interface{}{
-				"user":    user,
-				"expires": payload.Expiration,
-			})
-
-			ui.Say("%s", msg)
-		}
-
-		if err != nil {
-			err = errors.NewError(err)
-		}
-
-		return err
+	credential.Server = url
+	if credential.Method == "" {
+		credential.Method = method
 	}
 
-	// If there was an HTTP error condition, let's report it now.
-	if err == nil {
-		switch r.StatusCode() {
-		case http.StatusUnauthorized:
-			err = errors.ErrNoCredentials
-
-		case http.StatusForbidden:
-			err = errors.ErrInvalidCredentials
+	rest.SetCredential(credential)
 
-		case http.StatusNotFound:
-			err = errors.ErrLogonEndpoint
-
-		default:
-			err = errors.ErrHTTP.Context(r.StatusCode())
-		}
+	if err = settings.Save(); err != nil {
+		return errors.NewError(err)
 	}
 
-	if err != nil {
-		err = errors.NewError(err)
+	if credential.Expiration == "" {
+		ui.Say("%s", i18n.M("logged.in.no.expiration", map[string]interface{}{
+			"user": credential.User,
+		}))
+	} else {
+		ui.Say("%s", i18n.M("logged.in", map[string]interface{}{
+			"user":    credential.User,
+			"expires": credential.Expiration,
+		}))
 	}
 
-	return err
+	return nil
 }
 
 // Resolve a name that may not be fully qualified, and make it the default
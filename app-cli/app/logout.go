@@ -0,0 +1,163 @@
+package app
+
+import (
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/cli"
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/tables"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/i18n"
+	"github.com/tucats/gopackages/rest"
+)
+
+// LogoutGrammar describes the logout subcommand options.
+var LogoutGrammar = []cli.Option{
+	{
+		LongName:            "logon-server",
+		ShortName:           "l",
+		Aliases:             []string{"server"},
+		OptionType:          cli.StringType,
+		Description:         "logon.server",
+		EnvironmentVariable: "APP_LOGON_SERVER",
+	},
+	{
+		LongName:    "all",
+		ShortName:   "a",
+		OptionType:  cli.BooleanType,
+		Description: "logout.all",
+	},
+	{
+		LongName:    "revoke",
+		OptionType:  cli.BooleanType,
+		Description: "logout.revoke",
+	},
+}
+
+// Logout handles the logout subcommand. This removes the stored token
+// for the logon server from the user's profile. If the --all option is
+// given, the tokens for all servers are removed. If the --revoke option
+// is given, the server is first asked to revoke the token, so it cannot
+// be used again even if it was copied from the profile.
+func Logout(c *cli.Context) error {
+	var servers []string
+
+	if c.Boolean("all") {
+		for _, credential := range storedCredentials() {
+			servers = append(servers, credential.Server)
+		}
+	} else {
+		server := settings.Get(defs.LogonServerSetting)
+		if c.WasFound("logon-server") {
+			server, _ = c.String("logon-server")
+		}
+
+		if server == "" {
+			return errors.ErrNoLogonServer
+		}
+
+		servers = []string{server}
+	}
+
+	if len(servers) == 0 {
+		ui.Say("%s", i18n.M("logon.none"))
+
+		return nil
+	}
+
+	var revokeError error
+
+	for _, server := range servers {
+		// A credential without a token, such as one for basic
+		// authentication, has nothing for the server to revoke.
+		if credential, found := rest.GetCredential(server); found && credential.Token != "" && c.Boolean("revoke") {
+			if err := rest.RevokeCredential(server); err != nil {
+				ui.Log(ui.RestLogger, "Unable to revoke token for %s, %v", server, err)
+
+				revokeError = err
+			}
+		}
+
+		if !rest.DeleteCredential(server) {
+			if !c.Boolean("all") {
+				return errors.ErrNotLoggedOn.Context(server)
+			}
+
+			continue
+		}
+
+		ui.Say("%s", i18n.M("logged.out", map[string]interface{}{"server": server}))
+	}
+
+	if err := settings.Save(); err != nil {
+		return err
+	}
+
+	// The local tokens are removed even if the server could not revoke
+	// them, but the caller needs to know the revoke failed.
+	return revokeError
+}
+
+// LogonStatus handles the "logon status" subcommand. This displays the
+// server, user, token expiration, and time remaining for each server the
+// user is logged on to. The output is formatted using the current output
+// format setting.
+func LogonStatus(c *cli.Context) error {
+	credentials := storedCredentials()
+
+	if len(credentials) == 0 && ui.OutputFormat == ui.TextFormat {
+		ui.Say("%s", i18n.M("logon.none"))
+
+		return nil
+	}
+
+	t, _ := tables.New([]string{
+		i18n.L("Server"),
+		i18n.L("User"),
+		i18n.L("Expires"),
+		i18n.L("Remaining"),
+	})
+
+	for _, credential := range credentials {
+		remaining := ""
+
+		if expires, err := credential.Expires(); err == nil && !expires.IsZero() {
+			if until := time.Until(expires); until > 0 {
+				remaining = until.Round(time.Second).String()
+			} else {
+				remaining = i18n.L("expired")
+			}
+		}
+
+		_ = t.AddRowItems(credential.Server, credential.User, credential.Expiration, remaining)
+	}
+
+	t.SetPagination(0, 0)
+	t.ShowUnderlines(false)
+
+	return t.Print(ui.OutputFormat)
+}
+
+// storedCredentials returns the credentials stored for each server. If there
+// is a default token that predates the per-server credential store, it is
+// included unless the default server also has a stored credential. A normal
+// logon stores both, and the server must only be listed once.
+func storedCredentials() []rest.Credential {
+	credentials := rest.Credentials()
+
+	if server := settings.Get(defs.LogonServerSetting); server != "" {
+		if credential, found := rest.GetCredential(server); found {
+			for _, c := range credentials {
+				if c.Server == credential.Server {
+					return credentials
+				}
+			}
+
+			credentials = append(credentials, credential)
+		}
+	}
+
+	return credentials
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/cli"
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/rest"
+)
+
+func TestLogoutAllRevoke(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("app-test", "default")
+
+	revoked := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, defs.ServicesLogoutPath) {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		revoked[r.Header.Get("Authorization")]++
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// A normal logon to the default server stores both the per-server
+	// credential and the default token.
+	settings.Set(defs.LogonServerSetting, server.URL+"/")
+	rest.SetCredential(rest.Credential{Server: server.URL, User: "joe", Token: "default-token"})
+	rest.SetCredential(rest.Credential{Server: server.URL + "/other", User: "joe", Token: "other-token"})
+
+	c := &cli.Context{Grammar: []cli.Option{
+		{LongName: "all", OptionType: cli.BooleanType, Found: true, Value: true},
+		{LongName: "revoke", OptionType: cli.BooleanType, Found: true, Value: true},
+	}}
+
+	if err := Logout(c); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+
+	if revoked["Bearer default-token"] != 1 || revoked["Bearer other-token"] != 1 {
+		t.Errorf("Logout() revoked %v, want each token once", revoked)
+	}
+
+	if len(storedCredentials()) != 0 || settings.Get(defs.LogonTokenSetting) != "" {
+		t.Errorf("Logout() did not remove every token")
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+// Multipliers for the suffixes allowed on a log size setting.
+var sizeSuffixes = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1024 * 1024 * 1024},
+	{"MB", 1024 * 1024},
+	{"KB", 1024},
+	{"G", 1024 * 1024 * 1024},
+	{"M", 1024 * 1024},
+	{"K", 1024},
+	{"B", 1},
+}
+
+// setLogRotation sets the log file rotation and retention policy from the
+// profile settings.
+func setLogRotation() error {
+	if text := settings.Get(defs.LogMaxSizeSetting); text != "" {
+		size, err := parseSize(text)
+		if err != nil {
+			return err
+		}
+
+		ui.LogMaxSize = size
+	}
+
+	if text := settings.Get(defs.LogRetainSetting); text != "" {
+		count, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || count < 1 {
+			return errors.ErrInvalidInteger.Context(text)
+		}
+
+		ui.LogRetainCount = count
+	}
+
+	if text := settings.Get(defs.LogMaxAgeSetting); text != "" {
+		age, err := parseAge(text)
+		if err != nil {
+			return err
+		}
+
+		ui.LogMaxAge = age
+	}
+
+	ui.LogCompress = settings.GetBool(defs.LogCompressSetting)
+
+	return nil
+}
+
+// parseSize converts a size, which is a number of bytes optionally followed by
+// a suffix such as "KB" or "MB", to a number of bytes.
+func parseSize(text string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(text))
+	multiplier := int64(1)
+
+	for _, s := range sizeSuffixes {
+		if strings.HasSuffix(value, s.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, s.suffix))
+			multiplier = s.multiplier
+
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, errors.ErrInvalidValue.Context(text)
+	}
+
+	return size * multiplier, nil
+}
+
+// parseAge converts a duration to a time.Duration. In addition to the units
+// allowed by time.ParseDuration(), a whole number of days can be given using
+// the "d" suffix.
+func parseAge(text string) (time.Duration, error) {
+	value := strings.TrimSpace(text)
+
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		count, err := strconv.Atoi(days)
+		if err != nil || count < 0 {
+			return 0, errors.ErrInvalidValue.Context(text)
+		}
+
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, errors.ErrInvalidValue.Context(text)
+	}
+
+	return age, nil
+}
+
+// setLogRedaction sets the masking of sensitive values in log messages from
+// the profile settings.
+func setLogRedaction() {
+	if settings.Get(defs.LogRedactSetting) != "" {
+		ui.RedactLogs = settings.GetBool(defs.LogRedactSetting)
+	}
+
+	for _, name := range strings.Split(settings.Get(defs.LogRedactKeysSetting), ",") {
+		ui.AddRedactedKeys(name)
+	}
+}
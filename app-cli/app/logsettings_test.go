@@ -0,0 +1,110 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/ui"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    int64
+		wantErr bool
+	}{
+		{text: "1024", want: 1024},
+		{text: "10KB", want: 10 * 1024},
+		{text: "5 mb", want: 5 * 1024 * 1024},
+		{text: "2G", want: 2 * 1024 * 1024 * 1024},
+		{text: "large", wantErr: true},
+		{text: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSize(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSize(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+		}
+
+		if err == nil && got != tt.want {
+			t.Errorf("parseSize(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{text: "7d", want: 7 * 24 * time.Hour},
+		{text: "36h", want: 36 * time.Hour},
+		{text: "soon", wantErr: true},
+		{text: "xd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAge(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAge(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+		}
+
+		if err == nil && got != tt.want {
+			t.Errorf("parseAge(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseLogSink(t *testing.T) {
+	tests := []struct {
+		items    []string
+		kind     string
+		argument string
+		loggers  []string
+		wantErr  bool
+	}{
+		{items: []string{"stdout"}, kind: "stdout"},
+		{items: []string{"stderr:rest", "server"}, kind: "stderr", loggers: []string{"rest", "server"}},
+		{items: []string{"file:sql", "db=/tmp/sql.log"}, kind: "file", argument: "/tmp/sql.log", loggers: []string{"sql", "db"}},
+		{items: []string{"syslog=/dev/log"}, kind: "syslog", argument: "/dev/log"},
+		{items: []string{"memory:AUTH=100"}, kind: "memory", argument: "100", loggers: []string{"auth"}},
+		{items: []string{"stderr:nosuchlogger"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		specs := joinLogSinkSpecs(tt.items)
+		if len(specs) != 1 {
+			t.Errorf("joinLogSinkSpecs(%q) = %q, want one specification", tt.items, specs)
+
+			continue
+		}
+
+		kind, argument, classes, err := parseLogSink(specs[0])
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLogSink(%q) error = %v, wantErr %v", specs[0], err, tt.wantErr)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if kind != tt.kind || argument != tt.argument || len(classes) != len(tt.loggers) {
+			t.Errorf("parseLogSink(%q) = %q, %q, %v", specs[0], kind, argument, classes)
+
+			continue
+		}
+
+		for n, name := range tt.loggers {
+			if classes[n] != ui.LoggerByName(name) {
+				t.Errorf("parseLogSink(%q) logger %d = %d, want %s", specs[0], n, classes[n], name)
+			}
+		}
+	}
+
+	// Separate sinks, and sinks without loggers, are not joined.
+	if specs := joinLogSinkSpecs([]string{"stderr:rest", "file=rest.log", "stdout", "memory"}); len(specs) != 4 {
+		t.Errorf("joinLogSinkSpecs() joined separate sinks: %q", specs)
+	}
+}
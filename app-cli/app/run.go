@@ -18,6 +18,18 @@ func runFromContext(context *cli.Context) error {
 	// Load the active profile, if any from the profile for this application.
 	_ = settings.Load(context.AppName, "default")
 
+	// Apply the profile's log redaction settings.
+	setLogRedaction()
+
+	// Apply the profile's setting for recording the stacks of errors.
+	setErrorStacks()
+
+	// Load any message catalogs for the profile, which replace the ones
+	// built into the application.
+	if err := loadCatalogs(); err != nil {
+		return err
+	}
+
 	context.Grammar = applicationGrammar
 
 	// If we are to dump the grammar (a diagnostic function) do that,
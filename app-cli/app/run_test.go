@@ -4,6 +4,7 @@
 package app
 
 import (
+	goerror "errors"
 	"fmt"
 	"strconv"
 	"testing"
@@ -199,3 +200,46 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRunErrors(t *testing.T) {
+	grammar := []cli.Option{
+		{
+			LongName:    "missing",
+			OptionType:  cli.Subcommand,
+			Description: "fails with a mapped error",
+			Action: func(c *cli.Context) error {
+				return errors.ErrNotFound
+			},
+		},
+		{
+			LongName:    "broken",
+			OptionType:  cli.Subcommand,
+			Description: "fails with an error that is not mapped",
+			Action: func(c *cli.Context) error {
+				return errors.ErrInvalidLogLevel
+			},
+		},
+	}
+
+	app := New("test-driver")
+
+	// A mapped error is wrapped, but can still be found.
+	err := app.Run(grammar, []string{"test-driver", "missing"})
+
+	var exitError cli.ExitError
+
+	var cause *errors.Error
+
+	if !goerror.As(err, &exitError) || exitError.ExitStatus != cli.ExitNotFoundError {
+		t.Errorf("Run() = %#v, want exit status %d", err, cli.ExitNotFoundError)
+	}
+
+	if !goerror.Is(err, errors.ErrNotFound) || !goerror.As(err, &cause) || !cause.Is(errors.ErrNotFound) {
+		t.Errorf("Run() = %v does not wrap %v", err, errors.ErrNotFound)
+	}
+
+	// Any other error is returned unchanged.
+	if err := app.Run(grammar, []string{"test-driver", "broken"}); err != errors.ErrInvalidLogLevel {
+		t.Errorf("Run() = %#v, want %v", err, errors.ErrInvalidLogLevel)
+	}
+}
@@ -3,6 +3,9 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
+
+	"github.com/tucats/gopackages/errors"
 )
 
 // Exit codes passed to the operating system.
@@ -15,6 +18,15 @@ const (
 
 	// The application had an error in command line specification or environment.
 	ExitUsageError = 2
+
+	// The user is not logged on, or the server rejected the credentials.
+	ExitAuthError = 3
+
+	// The server could not be reached, or is unavailable.
+	ExitNetworkError = 4
+
+	// The requested item, such as a user or profile, does not exist.
+	ExitNotFoundError = 5
 )
 
 // ExitError is a wrapped error code structure used to return a message
@@ -22,6 +34,9 @@ const (
 type ExitError struct {
 	ExitStatus int
 	Message    string
+
+	// The error that caused the program to exit, if any.
+	Err error
 }
 
 // Error formats an ExitError into a text string for display.
@@ -29,13 +44,42 @@ func (e ExitError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the error that caused the program to exit, if any.
+func (e ExitError) Unwrap() error {
+	return e.Err
+}
+
 // NewExitError constructs an ExitError.
 func NewExitError(msg string, code int) ExitError {
 	return ExitError{ExitStatus: code, Message: msg}
 }
 
+// ExitErrorFor constructs an ExitError for the error that caused the program
+// to exit.
+func ExitErrorFor(err error, code int) ExitError {
+	return ExitError{ExitStatus: code, Message: err.Error(), Err: err}
+}
+
+// Report returns the text that describes why the program exited. If errors
+// are recording their Go call stacks, as they do when the --debug option is
+// used, this is the verbose form of the error, with each of its causes and
+// their stacks on separate lines, suitable for a bug report. Otherwise it is
+// the error message.
+func (e ExitError) Report() string {
+	if e.Err != nil && errors.CapturingStacks() {
+		return strings.TrimSuffix(errors.Verbose(e.Err), "\n")
+	}
+
+	return e.Message
+}
+
 // Exit exits the program, returning the given exit status code to the operating system.
+// If the ExitError was created for an error, its report is first written to stderr.
 func (e ExitError) Exit() {
+	if e.Err != nil {
+		fmt.Fprintln(os.Stderr, e.Report())
+	}
+
 	os.Exit(e.ExitStatus)
 }
 
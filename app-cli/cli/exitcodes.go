@@ -0,0 +1,187 @@
+package cli
+
+import (
+	goerror "errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/i18n"
+)
+
+// exitMapping maps the errors that match a function to an exit code.
+type exitMapping struct {
+	code  int
+	match func(error) bool
+}
+
+// The descriptions of the exit codes, which are shown in the help text. Each
+// description is an i18n key or text.
+var exitCodes = map[int]string{
+	ExitSuccess:       "exit.success",
+	ExitGeneralError:  "exit.general",
+	ExitUsageError:    "exit.usage",
+	ExitAuthError:     "exit.auth",
+	ExitNetworkError:  "exit.network",
+	ExitNotFoundError: "exit.not.found",
+}
+
+// The mappings of errors to exit codes. Later mappings take precedence, so an
+// application can change the exit code of an error that is already mapped.
+var exitMappings = []exitMapping{
+	{code: ExitUsageError, match: isError(
+		errors.ErrInvalidBooleanValue,
+		errors.ErrInvalidInteger,
+		errors.ErrInvalidKeyword,
+		errors.ErrInvalidLanguage,
+		errors.ErrMissingOptionValue,
+		errors.ErrRequiredNotFound,
+		errors.ErrTooManyParameters,
+		errors.ErrUnexpectedParameters,
+		errors.ErrUnknownOption,
+		errors.ErrUnrecognizedCommand,
+		errors.ErrUnsupportedOnOS,
+		errors.ErrWrongParameterCount,
+	)},
+	{code: ExitAuthError, match: isError(
+		errors.ErrExpiredToken,
+		errors.ErrInvalidCredentials,
+		errors.ErrNoCredentials,
+		errors.ErrNoLogonServer,
+		errors.ErrNotLoggedOn,
+	)},
+	{code: ExitAuthError, match: hasStatus(http.StatusUnauthorized, http.StatusForbidden)},
+	{code: ExitNetworkError, match: isNetworkError},
+	{code: ExitNetworkError, match: hasStatus(http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout)},
+	{code: ExitNotFoundError, match: isError(
+		errors.ErrNoSuchProfile,
+		errors.ErrNoSuchUser,
+		errors.ErrNotFound,
+	)},
+	{code: ExitNotFoundError, match: hasStatus(http.StatusNotFound)},
+}
+
+var exitMutex sync.Mutex
+
+// DefineExitCode adds an exit code to those listed in the help text, or
+// changes the description of one. The description is an i18n key or text.
+func DefineExitCode(code int, description string) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+
+	exitCodes[code] = description
+}
+
+// MapExitCode sets the exit code used when the application exits because of
+// one of the given errors, or an error that wraps one of them. This takes
+// precedence over any existing mapping for the errors.
+func MapExitCode(code int, errs ...error) {
+	MapExitCodeFunc(code, isError(errs...))
+}
+
+// MapExitCodeFunc sets the exit code used when the application exits because
+// of an error for which the function returns true. This is used for classes
+// of errors, such as any network error. It takes precedence over any existing
+// mapping for the errors.
+func MapExitCodeFunc(code int, match func(error) bool) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+
+	exitMappings = append(exitMappings, exitMapping{code: code, match: match})
+}
+
+// ExitStatus returns the exit code for an error. If the error is, or wraps,
+// an ExitError, its exit status is used. Otherwise, the most recent mapping
+// that matches the error is used. If there is none, the exit code is
+// ExitGeneralError, or ExitSuccess if there is no error.
+func ExitStatus(err error) int {
+	if errors.Nil(err) {
+		return ExitSuccess
+	}
+
+	var exitError ExitError
+
+	if goerror.As(err, &exitError) {
+		return exitError.ExitStatus
+	}
+
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+
+	for n := len(exitMappings) - 1; n >= 0; n-- {
+		if exitMappings[n].match(err) {
+			return exitMappings[n].code
+		}
+	}
+
+	return ExitGeneralError
+}
+
+// sortedExitCodes returns the exit codes and their descriptions, in order
+// of the exit code.
+func sortedExitCodes() ([]int, []string) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+
+	codes := make([]int, 0, len(exitCodes))
+	for code := range exitCodes {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+
+	descriptions := make([]string, len(codes))
+
+	for n, code := range codes {
+		descriptions[n] = i18n.T(exitCodes[code])
+		if descriptions[n] == exitCodes[code] {
+			descriptions[n] = i18n.L(exitCodes[code])
+		}
+	}
+
+	return codes, descriptions
+}
+
+// isError returns a function that reports whether an error is one of the
+// given errors.
+func isError(errs ...error) func(error) bool {
+	return func(err error) bool {
+		for _, target := range errs {
+			if goerror.Is(err, target) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// isNetworkError reports whether an error is a network error, such as a
+// connection that was refused or timed out.
+func isNetworkError(err error) bool {
+	var netError net.Error
+
+	return goerror.As(err, &netError)
+}
+
+// hasStatus returns a function that reports whether an error was caused by
+// an HTTP response with one of the given statuses, such as a rest.HTTPError.
+func hasStatus(statuses ...int) func(error) bool {
+	return func(err error) bool {
+		var response interface{ StatusCode() int }
+
+		if !goerror.As(err, &response) {
+			return false
+		}
+
+		for _, status := range statuses {
+			if response.StatusCode() == status {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/tucats/gopackages/errors"
+)
+
+// statusError is an error caused by an HTTP response, like a rest.HTTPError.
+type statusError int
+
+func (e statusError) Error() string   { return fmt.Sprintf("status %d", int(e)) }
+func (e statusError) StatusCode() int { return int(e) }
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "no error", err: nil, want: ExitSuccess},
+		{name: "unmapped error", err: errors.ErrInvalidLogLevel, want: ExitGeneralError},
+		{name: "usage error", err: errors.ErrUnknownOption.With("option", "--bogus"), want: ExitUsageError},
+		{name: "wrapped auth error", err: fmt.Errorf("logon: %w", errors.ErrNoCredentials), want: ExitAuthError},
+		{name: "not logged on", err: errors.ErrNotLoggedOn.With("server", "localhost"), want: ExitAuthError},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}, want: ExitNetworkError},
+		{name: "server unavailable", err: statusError(503), want: ExitNetworkError},
+		{name: "unauthorized", err: statusError(401), want: ExitAuthError},
+		{name: "not found", err: statusError(404), want: ExitNotFoundError},
+		{name: "other status", err: statusError(500), want: ExitGeneralError},
+		{name: "cause not found", err: errors.Join(errors.ErrInvalidLogLevel, errors.ErrNoSuchUser), want: ExitNotFoundError},
+		{name: "exit error", err: NewExitError("stopped", 9), want: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitStatus(tt.err); got != tt.want {
+				t.Errorf("ExitStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	savedMappings := exitMappings
+	defer func() { exitMappings = savedMappings }()
+
+	MapExitCode(10, errors.ErrNoSuchUser, errors.ErrInvalidLogLevel)
+
+	if got := ExitStatus(errors.ErrNoSuchUser.With("user", "tom")); got != 10 {
+		t.Errorf("ExitStatus() after MapExitCode() = %d, want 10", got)
+	}
+
+	if got := ExitStatus(errors.ErrNoSuchProfile); got != ExitNotFoundError {
+		t.Errorf("ExitStatus() of an error that was not remapped = %d", got)
+	}
+}
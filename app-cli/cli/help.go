@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/tucats/gopackages/app-cli/tables"
@@ -256,4 +257,31 @@ func ShowHelp(c *Context) {
 	_ = to.AddRow([]string{"--help, -h", i18n.O("help.text")})
 	_ = to.SortRows(0, true)
 	_ = to.Print(ui.TextFormat)
+
+	// The exit codes apply to the whole program, so they are only shown
+	// in the help for the program itself.
+	if c.Parent == nil {
+		showExitCodes(minimumFirstColumnWidth)
+	}
+}
+
+// showExitCodes displays the exit codes of the program and their meanings.
+func showExitCodes(minimumFirstColumnWidth int) {
+	te, _ := tables.New([]string{"code", "description"})
+
+	te.ShowHeadings(false)
+	te.SetPagination(0, 0)
+
+	_ = te.SetIndent(helpIndent)
+	_ = te.SetSpacing(helpSpacing)
+	_ = te.SetMinimumWidth(0, minimumFirstColumnWidth)
+
+	codes, descriptions := sortedExitCodes()
+	for n, code := range codes {
+		_ = te.AddRow([]string{strconv.Itoa(code), descriptions[n]})
+	}
+
+	fmt.Printf("\n%s:\n", i18n.L("exit.codes"))
+
+	_ = te.Print(ui.TextFormat)
 }
@@ -145,6 +145,12 @@ func (c *Context) parseGrammar(args []string) error {
 
 		if name > "" {
 			for n, entry := range c.Grammar {
+				// A subcommand is never named with a leading dash, so it cannot
+				// hide an option with the same name, such as --log.
+				if entry.OptionType == Subcommand {
+					continue
+				}
+
 				if (isShort && entry.ShortName == name) || (!isShort && entry.LongName == name) {
 					location = &(c.Grammar[n])
 
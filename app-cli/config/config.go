@@ -4,6 +4,7 @@ package config
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/tucats/gopackages/app-cli/cli"
@@ -18,6 +19,13 @@ import (
 
 const maxKeyValuePrintWidth = 60
 
+// The settings that hold tokens or other secrets. Their values are never
+// shown.
+var secretSettings = []string{
+	defs.LogonCredentialsSetting,
+	defs.LogonTokenSetting,
+}
+
 // Grammar describes the "config" subcommands.
 var Grammar = []cli.Option{
 	{
@@ -84,6 +92,22 @@ var Grammar = []cli.Option{
 		ParametersExpected:   1,
 		ParameterDescription: "parm.config.key.value",
 	},
+	{
+		LongName:             "history",
+		Description:          "app.config.history",
+		Action:               HistoryAction,
+		OptionType:           cli.Subcommand,
+		ParametersExpected:   -1,
+		ParameterDescription: "parm.version",
+	},
+	{
+		LongName:             "rollback",
+		Description:          "app.config.rollback",
+		Action:               RollbackAction,
+		OptionType:           cli.Subcommand,
+		ParametersExpected:   1,
+		ParameterDescription: "parm.version",
+	},
 }
 
 // ShowAction implements the "config show" subcommand. This displays the
@@ -96,7 +120,7 @@ func ShowAction(c *cli.Context) error {
 			return errors.ErrNoSuchProfileKey.Context(key)
 		}
 
-		fmt.Println(settings.Get(key))
+		fmt.Println(showValue(key, settings.Get(key)))
 
 		return nil
 	}
@@ -104,6 +128,8 @@ func ShowAction(c *cli.Context) error {
 	t, _ := tables.New([]string{i18n.L("Key"), i18n.L("Value")})
 
 	for k, v := range settings.CurrentConfiguration.Items {
+		v = showValue(k, v)
+
 		if len(fmt.Sprintf("%v", v)) > maxKeyValuePrintWidth {
 			v = fmt.Sprintf("%v", v)[:maxKeyValuePrintWidth] + "..."
 		}
@@ -121,6 +147,16 @@ func ShowAction(c *cli.Context) error {
 	return nil
 }
 
+// showValue returns the value of a setting as it is shown to the user. The
+// value of a setting that holds a secret is masked.
+func showValue(key, value string) string {
+	if value != "" && util.InList(key, secretSettings...) {
+		return ui.RedactedText
+	}
+
+	return value
+}
+
 // ListAction implements the "config list" subcommand. This displays the
 // list of configuration names.
 func ListAction(c *cli.Context) error {
@@ -252,3 +288,95 @@ func validateKey(key string) error {
 
 	return nil
 }
+
+// HistoryAction implements the "config history" subcommand. With no
+// parameter, this lists the prior versions of the active configuration,
+// most recent first, along with the keys that changed in each version.
+// If a version number is given, the items in that version are displayed.
+func HistoryAction(c *cli.Context) error {
+	history := settings.History()
+
+	if c.ParameterCount() > 0 {
+		version, err := historyVersion(c.Parameter(0), len(history))
+		if err != nil {
+			return err
+		}
+
+		t, _ := tables.New([]string{i18n.L("Key"), i18n.L("Value")})
+
+		for k, v := range history[version-1].Items {
+			v = showValue(k, v)
+
+			if len(v) > maxKeyValuePrintWidth {
+				v = v[:maxKeyValuePrintWidth] + "..."
+			}
+
+			_ = t.AddRowItems(k, v)
+		}
+
+		t.SetPagination(0, 0)
+
+		_ = t.SetOrderBy(i18n.L("Key"))
+		t.ShowUnderlines(false)
+
+		return t.Print(ui.OutputFormat)
+	}
+
+	if len(history) == 0 && ui.OutputFormat == ui.TextFormat {
+		ui.Say("%s", i18n.M("config.no.history", map[string]interface{}{"name": settings.ProfileName}))
+
+		return nil
+	}
+
+	t, _ := tables.New([]string{i18n.L("Version"), i18n.L("Modified"), i18n.L("Replaced"), i18n.L("Changed")})
+
+	// Each version is compared with the version that replaced it, which for
+	// the most recent history entry is the current configuration.
+	newer := settings.CurrentConfiguration.Items
+
+	for n, entry := range history {
+		changed := strings.Join(settings.ChangedKeys(entry.Items, newer), ", ")
+		_ = t.AddRowItems(n+1, entry.Modified, entry.Replaced, changed)
+
+		newer = entry.Items
+	}
+
+	t.SetPagination(0, 0)
+
+	_ = t.SetAlignment(0, tables.AlignmentRight)
+	t.ShowUnderlines(false)
+
+	return t.Print(ui.OutputFormat)
+}
+
+// RollbackAction implements the "config rollback" subcommand. This restores
+// the active configuration to the values in the given history version. The
+// values being replaced are themselves added to the history.
+func RollbackAction(c *cli.Context) error {
+	version, err := historyVersion(c.Parameter(0), len(settings.History()))
+	if err != nil {
+		return err
+	}
+
+	if err = settings.Rollback(version); err != nil {
+		return err
+	}
+
+	ui.Say("%s", i18n.M("config.rolled.back", map[string]interface{}{
+		"name":    settings.ProfileName,
+		"version": version,
+	}))
+
+	return nil
+}
+
+// Convert a version string from the command line into a history version
+// number, and verify that it is in the range of the available history.
+func historyVersion(text string, count int) (int, error) {
+	version, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || version < 1 || version > count {
+		return 0, errors.ErrInvalidHistoryVersion.Context(text)
+	}
+
+	return version, nil
+}
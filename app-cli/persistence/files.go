@@ -1,197 +1,100 @@
+// Package persistence is retained for compatibility with older applications.
+// It forwards all operations to the settings package, so values read or
+// written using either package always refer to the same profile.
+//
+// The exported variables are copies of those in the settings package, which
+// are updated by each function in this package that changes the profile. A
+// value set by another package is not seen until one of those functions is
+// called, so new code should read the settings package variables instead.
+//
+// Deprecated: use the settings package instead.
 package persistence
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"os"
-	"strings"
-
-	"github.com/google/uuid"
-	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/app-cli/settings"
 )
 
 // ProfileDirectory is the name of the invisible directory that is created
-// in the user's home directory to host configuration data
+// in the user's home directory to host configuration data. To use a
+// different directory, set settings.ProfileDirectory.
 const ProfileDirectory = ".org.fernwood"
 
 // ProfileFile is the name of the configuration file that contains the
 // profiles.
-var ProfileFile = "config.json"
+var ProfileFile = settings.ProfileFile
 
 // ProfileName is the name of the configuration being used. The default
-// configuration is always named "default"
-var ProfileName = "default"
+// configuration is always named "default".
+var ProfileName = settings.ProfileName
 
-// Configuration describes what is known about a configuration
-type Configuration struct {
-	Description string            `json:"description,omitempty"`
-	ID          string            `json:"id,omitempty"`
-	Items       map[string]string `json:"items"`
-}
+// Configuration describes what is known about a configuration.
+type Configuration = settings.Configuration
 
 // CurrentConfiguration describes the current configuration that is active.
+// It is the same configuration as settings.CurrentConfiguration.
 var CurrentConfiguration *Configuration
 
-// explicitValues contains overridden default values
-var explicitValues = Configuration{Description: "overridden defaults", Items: map[string]string{}}
-
 // ProfileDirty is set to true when a key value is written or deleted, which
 // tells us to rewrite the profile. If false, then no update is required.
+// Setting it to true before calling Save() causes the profile to be written.
 var ProfileDirty = false
 
 // Configurations is a map keyed by the configuration name for each
 // configuration in the config file.
-//
-// @TOMCOLE This is currently a public symbol. Is this really needed?
-// Check on the JSON code to determine if it can handle a private
-// symbol at the top level...
 var Configurations map[string]Configuration
 
 // Load reads in the named profile, if it exists.
 func Load(application string, name string) error {
+	defer refresh()
 
-	var c Configuration = Configuration{Description: "Default configuration", Items: map[string]string{}}
-
-	CurrentConfiguration = &c
-	Configurations = map[string]Configuration{"default": c}
-	ProfileFile = application + ".json"
-
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-
-	var path strings.Builder
-	path.WriteString(home)
-	path.WriteRune(os.PathSeparator)
-	path.WriteString(ProfileDirectory)
-	path.WriteRune(os.PathSeparator)
-	path.WriteString(ProfileFile)
-
-	configFile, err := os.Open(path.String())
-	if err != nil {
-		return err
-	}
-
-	defer configFile.Close()
-	// read our opened jsonFile as a byte array.
-	byteValue, _ := ioutil.ReadAll(configFile)
-
-	// we unmarshal our byteArray which contains our
-	// jsonFile's content into the config map which we defined above
-	err = json.Unmarshal(byteValue, &Configurations)
-	if err == nil {
-		if name == "" {
-			name = ProfileName
-		}
-		c, found := Configurations[name]
-		if !found {
-			c = Configuration{Description: "Default configuration", Items: map[string]string{}}
-			Configurations[name] = c
-			ProfileDirty = true
-		}
-		ProfileName = name
-		CurrentConfiguration = &c
-	}
-
-	return err
+	return settings.Load(application, name)
 }
 
 // Save the current configuration.
 func Save() error {
+	defer refresh()
 
-	// So we even need to do anything?
-	if !ProfileDirty {
-		return nil
-	}
-
-	// Does the directory exist?
-	var path strings.Builder
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-	path.WriteString(home)
-	path.WriteRune(os.PathSeparator)
-	path.WriteString(ProfileDirectory)
-
-	if _, err := os.Stat(path.String()); os.IsNotExist(err) {
-		_ = os.MkdirAll(path.String(), os.ModePerm)
+	if ProfileDirty {
+		settings.ProfileDirty = true
 	}
 
-	path.WriteRune(os.PathSeparator)
-	path.WriteString(ProfileFile)
-
-	// Make sure every configuration has an id
-	for n := range Configurations {
-		c := Configurations[n]
-		if c.ID == "" {
-			c.ID = uuid.New().String()
-			ui.Log(ui.AppLogger, "Creating configuration \"%s\" with id %s", n, c.ID)
-			Configurations[n] = c
-		}
-	}
-	byteBuffer, _ := json.MarshalIndent(&Configurations, "", "  ")
-
-	err = ioutil.WriteFile(path.String(), byteBuffer, os.ModePerm)
-	return err
+	return settings.Save()
 }
 
 // UseProfile specifies the name of the profile to use, if other
 // than the default.
 func UseProfile(name string) {
+	defer refresh()
 
-	c, found := Configurations[name]
-	if !found {
-		c = Configuration{Description: name + " configuration", Items: map[string]string{}}
-		Configurations[name] = c
-		ProfileDirty = true
-	}
-	ProfileName = name
-	CurrentConfiguration = &c
+	settings.UseProfile(name)
 }
 
-// Set puts a profile entry in the current Configuration structure
+// Set puts a profile entry in the current Configuration structure.
 func Set(key string, value string) {
-	explicitValues.Items[key] = value
-	c := getCurrentConfiguration()
-	c.Items[key] = value
-	ProfileDirty = true
-	ui.Log(ui.AppLogger, "Setting profile key \"%s\" = \"%s\"", key, value)
+	defer refresh()
+
+	settings.Set(key, value)
 }
 
 // SetDefault puts a profile entry in the current Configuration structure. It is
 // different than Set() in that it doesn't mark the value as dirty, so no need
 // to update on account of this setting.
 func SetDefault(key string, value string) {
-	explicitValues.Items[key] = value
-	ui.Log(ui.AppLogger, "Setting default key \"%s\" = \"%s\"", key, value)
+	defer refresh()
+
+	settings.SetDefault(key, value)
 }
 
 // Get gets a profile entry in the current configuration structure.
 // If the key does not exist, an empty string is returned.
 func Get(key string) string {
-
-	// First, search the default values that be explicitly set
-
-	v, found := explicitValues.Items[key]
-	if !found {
-		c := getCurrentConfiguration()
-		v = c.Items[key]
-	}
-	ui.Log(ui.AppLogger, "Reading profile key \"%s\" : \"%s\"", key, v)
-	return v
+	return settings.Get(key)
 }
 
 // GetBool returns the boolean value of a profile string. If the string is
 // "Y", "YES", "1", or "TRUE" then the value returns true.
 func GetBool(key string) bool {
-	s := strings.ToLower(Get(key))
-	if s == "y" || s == "yes" || s == "true" || s == "t" || s == "1" {
-		return true
-	}
-	return false
+	return settings.GetBool(key)
 }
 
 // Get a key value, and compare it to a list of provided values. If it
@@ -199,71 +102,45 @@ func GetBool(key string) bool {
 // (one-based) is returned. If the value is not in the list at all,
 // a result of 0 is returned.
 func GetUsingList(key string, values ...string) int {
-	v := strings.TrimSpace(strings.ToLower(Get(key)))
-
-	for position, value := range values {
-		if v == value {
-			return position + 1
-		}
-	}
-	return 0
+	return settings.GetUsingList(key, values...)
 }
 
 // Delete removes a key from the map entirely. Also removes if from the
-// active defaults.
+// active defaults. Unlike settings.Delete, it is not an error if the key
+// does not exist.
 func Delete(key string) {
-	c := getCurrentConfiguration()
-	delete(c.Items, key)
-	delete(explicitValues.Items, key)
-	ProfileDirty = true
-	ui.Log(ui.AppLogger, "Deleting profile key \"%s\"", key)
+	defer refresh()
+
+	_ = settings.Delete(key)
 }
 
 // Keys returns the list of keys in the profile as an array
 // of strings.
 func Keys() []string {
-	c := getCurrentConfiguration()
-	result := []string{}
-	for key := range c.Items {
-		result = append(result, key)
-	}
-	return result
+	return settings.Keys()
 }
 
-// Exists test to see if a key value exists or not
+// Exists test to see if a key value exists or not.
 func Exists(key string) bool {
-
-	_, exists := explicitValues.Items[key]
-	if !exists {
-		c := getCurrentConfiguration()
-		_, exists = c.Items[key]
-	}
-	return exists
+	return settings.Exists(key)
 }
 
+// DeleteProfile deletes an entire named configuration.
 func DeleteProfile(key string) error {
-	if cfg, ok := Configurations[key]; ok {
-		if cfg.ID == getCurrentConfiguration().ID {
-			ui.Log(ui.AppLogger, "cannot delete active profile")
-			return fmt.Errorf("cannot delete active profile")
-		}
-		delete(Configurations, key)
-		ProfileDirty = true
-		err := Save()
-		if err == nil {
-			ui.Log(ui.AppLogger, "deleted profile %s (%s)", key, cfg.ID)
-		}
-		return err
-	}
-	ui.Log(ui.AppLogger, "no such profile to delete: %s", key)
-	return fmt.Errorf("no such profile: %s", key)
+	defer refresh()
+
+	return settings.DeleteProfile(key)
 }
 
-func getCurrentConfiguration() *Configuration {
+// refresh copies the profile variables from the settings package.
+func refresh() {
+	ProfileFile = settings.ProfileFile
+	ProfileName = settings.ProfileName
+	ProfileDirty = settings.ProfileDirty
+	CurrentConfiguration = settings.CurrentConfiguration
 
-	if CurrentConfiguration == nil {
-		var c Configuration = Configuration{Description: "Default configuration", Items: map[string]string{}}
-		CurrentConfiguration = &c
+	Configurations = make(map[string]Configuration, len(settings.Configurations))
+	for name, configuration := range settings.Configurations {
+		Configurations[name] = *configuration
 	}
-	return CurrentConfiguration
 }
@@ -3,7 +3,11 @@
 // profile in as part of startup, and of updating the profile as needed.
 package persistence
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+)
 
 func TestLoad(t *testing.T) {
 	type args struct {
@@ -28,3 +32,30 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestCompatibilityVariables(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	// The memory store has no profiles yet, so the default profile is created.
+	_ = Load("persistence-test", "default")
+
+	Set("persistence.test", "value")
+	defer Delete("persistence.test")
+
+	if !ProfileDirty || ProfileName != "default" || ProfileFile != "persistence-test.json" {
+		t.Errorf("variables not updated: dirty %v, name %q, file %q", ProfileDirty, ProfileName, ProfileFile)
+	}
+
+	if CurrentConfiguration != settings.CurrentConfiguration || CurrentConfiguration.Items["persistence.test"] != "value" {
+		t.Errorf("CurrentConfiguration is not the settings configuration")
+	}
+
+	if Configurations["default"].Items["persistence.test"] != "value" {
+		t.Errorf("Configurations = %v", Configurations)
+	}
+
+	if err := Save(); err != nil || Configurations["default"].ID == "" {
+		t.Errorf("Save() = %v, configuration %v", err, Configurations["default"])
+	}
+}
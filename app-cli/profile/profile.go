@@ -6,7 +6,7 @@ import (
 	"strings"
 
 	"github.com/tucats/gopackages/app-cli/cli"
-	"github.com/tucats/gopackages/app-cli/persistence"
+	"github.com/tucats/gopackages/app-cli/settings"
 	"github.com/tucats/gopackages/app-cli/tables"
 	"github.com/tucats/gopackages/app-cli/ui"
 )
@@ -73,7 +73,7 @@ func ShowAction(c *cli.Context) error {
 
 	t, _ := tables.New([]string{"Key", "Value"})
 
-	for k, v := range persistence.CurrentConfiguration.Items {
+	for k, v := range settings.CurrentConfiguration.Items {
 		if len(fmt.Sprintf("%v", v)) > 60 {
 			v = fmt.Sprintf("%v", v)[:60] + "..."
 		}
@@ -91,7 +91,7 @@ func ListAction(c *cli.Context) error {
 
 	t, _ := tables.New([]string{"Name", "Description"})
 
-	for k, v := range persistence.Configurations {
+	for k, v := range settings.Configurations {
 		_ = t.AddRowItems(k, v.Description)
 	}
 	_ = t.SetOrderBy("name")
@@ -107,7 +107,7 @@ func SetOutputAction(c *cli.Context) error {
 	if c.ParameterCount() == 1 {
 		outputType := c.Parameter(0)
 		if outputType == "text" || outputType == "json" {
-			persistence.Set("app.output-format", outputType)
+			settings.Set("app.output-format", outputType)
 			return nil
 		}
 		return errors.New("Invalid output type: " + outputType)
@@ -126,7 +126,7 @@ func SetAction(c *cli.Context) error {
 		value = key[equals+1:]
 		key = key[:equals]
 	}
-	persistence.Set(key, value)
+	settings.Set(key, value)
 	ui.Say("Profile key %s written", key)
 
 	return nil
@@ -136,7 +136,9 @@ func SetAction(c *cli.Context) error {
 func DeleteAction(c *cli.Context) error {
 
 	key := c.Parameter(0)
-	persistence.Delete(key)
+	if err := settings.Delete(key); err != nil {
+		return err
+	}
 	ui.Say("Profile key %s deleted", key)
 
 	return nil
@@ -145,7 +147,7 @@ func DeleteAction(c *cli.Context) error {
 // DeleteProfileAction deletes a named profile.
 func DeleteProfileAction(c *cli.Context) error {
 	key := c.Parameter(0)
-	err := persistence.DeleteProfile(key)
+	err := settings.DeleteProfile(key)
 	if err == nil {
 		ui.Say("Profile %s deleted", key)
 		return nil
@@ -156,10 +158,10 @@ func DeleteProfileAction(c *cli.Context) error {
 // SetDescriptionAction sets the profile description string
 func SetDescriptionAction(c *cli.Context) error {
 
-	config := persistence.Configurations[persistence.ProfileName]
+	config := settings.Configurations[settings.ProfileName]
 	config.Description = c.Parameter(0)
-	persistence.Configurations[persistence.ProfileName] = config
-	persistence.ProfileDirty = true
+	settings.Configurations[settings.ProfileName] = config
+	settings.ProfileDirty = true
 
 	return nil
 }
@@ -1,13 +1,11 @@
 // Package settings manages the persistent user profile used by the command
 // application infrastructure. This includes automatically reading any
 // profile in as part of startup, and of updating the profile as needed.
+// The profile is read from and written to a storage backend, which is a
+// JSON file in the user's home directory unless changed using SetStore().
 package settings
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -36,6 +34,10 @@ var DefaultConfiguration = i18n.L("Default.configuration")
 // profiles.
 var ProfileFile = "config.json"
 
+// applicationName is the name of the application whose profile was loaded,
+// which is used to identify the profile in the active Store.
+var applicationName = "config"
+
 // ProfileName is the name of the configuration being used. The default
 // configuration is always named "default".
 var ProfileName = "default"
@@ -59,6 +61,11 @@ type Configuration struct {
 	// that configuration item. Configuration items that are not strings
 	// must be serialized as a string.
 	Items map[string]string `json:"items"`
+
+	// The History list contains prior versions of the configuration
+	// items, most recent first. The number of versions kept is limited
+	// by the history depth.
+	History []HistoryEntry `json:"history,omitempty"`
 }
 
 // CurrentConfiguration describes the current configuration that is active.
@@ -75,7 +82,8 @@ var ProfileDirty = false
 // configuration in the config file.
 var Configurations map[string]*Configuration
 
-// Load reads in the named profile, if it exists.
+// Load reads in the named profile, if it exists. The profile is read from
+// the active Store, which by default is a JSON file in the ProfileDirectory.
 func Load(application string, name string) error {
 	var c = Configuration{
 		Description: DefaultConfiguration,
@@ -85,70 +93,47 @@ func Load(application string, name string) error {
 	CurrentConfiguration = &c
 	Configurations = map[string]*Configuration{"default": CurrentConfiguration}
 	ProfileFile = application + ".json"
+	applicationName = application
 
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return errors.NewError(err)
-	}
-
-	path := filepath.Join(home, ProfileDirectory, ProfileFile)
-
-	configFile, err := os.Open(path)
+	configurations, err := store.Load(application)
 	if err != nil {
 		return errors.NewError(err)
 	}
 
-	defer configFile.Close()
-	// read our opened jsonFile as a byte array.
-	byteValue, _ := ioutil.ReadAll(configFile)
-
-	// we unmarshal our byteArray which contains our
-	// jsonFile's content into the config map which we defined above
-	err = json.Unmarshal(byteValue, &Configurations)
-	if err == nil {
-		if name == "" {
-			name = ProfileName
+	for key, configuration := range configurations {
+		if configuration.Items == nil {
+			configuration.Items = map[string]string{}
 		}
 
-		c, found := Configurations[name]
-
-		if !found {
-			c = &Configuration{Description: DefaultConfiguration, Items: map[string]string{}}
-			Configurations[name] = c
-			ProfileDirty = true
-		}
+		Configurations[key] = configuration
+	}
 
-		ProfileName = name
-		CurrentConfiguration = c
+	if name == "" {
+		name = ProfileName
 	}
 
-	if err != nil {
-		err = errors.NewError(err)
+	current, found := Configurations[name]
+	if !found {
+		current = &Configuration{Description: DefaultConfiguration, Items: map[string]string{}}
+		Configurations[name] = current
+		ProfileDirty = true
 	}
 
-	return err
+	ProfileName = name
+	CurrentConfiguration = current
+
+	snapshotConfigurations()
+
+	return nil
 }
 
-// Save the current configuration to persistent disk storage.
+// Save the current configuration to the active Store.
 func Save() error {
 	// So we even need to do anything?
 	if !ProfileDirty {
 		return nil
 	}
 
-	// Does the directory exist?
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return errors.NewError(err)
-	}
-
-	path := filepath.Join(home, ProfileDirectory)
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		_ = os.MkdirAll(path, securePermission)
-	}
-
-	path = filepath.Join(path, ProfileFile)
-
 	// Make sure every configuration has an id
 	for n := range Configurations {
 		c := Configurations[n]
@@ -160,14 +145,16 @@ func Save() error {
 		}
 	}
 
-	byteBuffer, _ := json.MarshalIndent(&Configurations, "", "  ")
-	err = ioutil.WriteFile(path, byteBuffer, securePermission)
+	// Record the prior values of any configuration that has changed.
+	recordHistory()
 
-	if err != nil {
-		err = errors.NewError(err)
+	if err := store.Save(applicationName, Configurations); err != nil {
+		return errors.NewError(err)
 	}
 
-	return err
+	snapshotConfigurations()
+
+	return nil
 }
 
 // UseProfile specifies the name of the profile to use, if other
@@ -0,0 +1,162 @@
+package settings
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+// DefaultHistoryDepth is the number of prior versions of each configuration
+// that are retained in the profile file when the depth has not been set
+// using the defs.ConfigHistoryDepthSetting profile key.
+var DefaultHistoryDepth = 10
+
+// HistoryEntry describes a prior version of a configuration. Each time the
+// profile is saved, the previous values of any configuration that changed
+// are recorded as a history entry.
+type HistoryEntry struct {
+	// The date and time the prior version was last modified, expressed as
+	// a string.
+	Modified string `json:"modified,omitempty"`
+
+	// The date and time this version was replaced, expressed as a string.
+	Replaced string `json:"replaced,omitempty"`
+
+	// The configuration values as they were before they were replaced.
+	Items map[string]string `json:"items"`
+}
+
+// savedItems contains a copy of the items in each configuration as they
+// were when last read from or written to the profile file. This is used
+// to detect which configurations have changed, and what their prior values
+// were, when the profile is saved.
+var savedItems = map[string]map[string]string{}
+
+// savedModified contains the modification time of each configuration as
+// it was when last read from or written to the profile file.
+var savedModified = map[string]string{}
+
+// History returns the list of prior versions of the current configuration.
+// The first item in the list is the most recent prior version.
+func History() []HistoryEntry {
+	return getCurrentConfiguration().History
+}
+
+// Rollback restores the items of the current configuration to the values
+// stored in the given history version. The version is one-based, where 1
+// is the most recent prior version. The current values are themselves
+// recorded as history when the profile is next saved.
+func Rollback(version int) error {
+	c := getCurrentConfiguration()
+
+	if version < 1 || version > len(c.History) {
+		return errors.ErrInvalidHistoryVersion.Context(version)
+	}
+
+	entry := c.History[version-1]
+
+	// Any explicit value for a key whose stored value is changing by
+	// the rollback would mask the restored value, so remove it.
+	for _, key := range ChangedKeys(c.Items, entry.Items) {
+		delete(explicitValues.Items, key)
+	}
+
+	c.Items = copyItems(entry.Items)
+	c.Modified = time.Now().Format(time.RFC1123Z)
+	ProfileDirty = true
+
+	ui.Log(ui.AppLogger, "Rolled back configuration \"%s\" to version %d from %s", ProfileName, version, entry.Modified)
+
+	return nil
+}
+
+// HistoryDepth returns the maximum number of history entries that are kept
+// for each configuration. A value of zero means no history is kept.
+func HistoryDepth() int {
+	if Exists(defs.ConfigHistoryDepthSetting) {
+		depth := GetInt(defs.ConfigHistoryDepthSetting)
+		if depth < 0 {
+			depth = 0
+		}
+
+		return depth
+	}
+
+	return DefaultHistoryDepth
+}
+
+// ChangedKeys compares two sets of configuration items and returns a sorted
+// list of the keys that were added, removed, or whose values differ.
+func ChangedKeys(before, after map[string]string) []string {
+	result := []string{}
+
+	for key, value := range before {
+		if newValue, found := after[key]; !found || newValue != value {
+			result = append(result, key)
+		}
+	}
+
+	for key := range after {
+		if _, found := before[key]; !found {
+			result = append(result, key)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// recordHistory compares each configuration to the values that were last
+// read or written, and for any that have changed, adds the prior values to
+// the front of the configuration's history list. The history is trimmed to
+// the configured depth.
+func recordHistory() {
+	depth := HistoryDepth()
+	now := time.Now().Format(time.RFC1123Z)
+
+	for name, c := range Configurations {
+		prior, found := savedItems[name]
+		if found && len(ChangedKeys(prior, c.Items)) > 0 && depth > 0 {
+			entry := HistoryEntry{
+				Modified: savedModified[name],
+				Replaced: now,
+				Items:    prior,
+			}
+
+			c.History = append([]HistoryEntry{entry}, c.History...)
+
+			ui.Log(ui.AppLogger, "Recording history for configuration \"%s\"", name)
+		}
+
+		if len(c.History) > depth {
+			c.History = c.History[:depth]
+		}
+	}
+}
+
+// snapshotConfigurations records the current state of each configuration
+// so changes can be detected when the profile is next saved.
+func snapshotConfigurations() {
+	savedItems = map[string]map[string]string{}
+	savedModified = map[string]string{}
+
+	for name, c := range Configurations {
+		savedItems[name] = copyItems(c.Items)
+		savedModified[name] = c.Modified
+	}
+}
+
+// copyItems makes a copy of a configuration item map.
+func copyItems(items map[string]string) map[string]string {
+	result := make(map[string]string, len(items))
+
+	for key, value := range items {
+		result[key] = value
+	}
+
+	return result
+}
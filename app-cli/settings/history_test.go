@@ -0,0 +1,102 @@
+package settings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]string
+		after  map[string]string
+		want   []string
+	}{
+		{
+			name:   "no changes",
+			before: map[string]string{"a": "1", "b": "2"},
+			after:  map[string]string{"a": "1", "b": "2"},
+			want:   []string{},
+		},
+		{
+			name:   "changed value",
+			before: map[string]string{"a": "1", "b": "2"},
+			after:  map[string]string{"a": "1", "b": "3"},
+			want:   []string{"b"},
+		},
+		{
+			name:   "added and removed keys",
+			before: map[string]string{"a": "1", "c": "2"},
+			after:  map[string]string{"b": "1", "c": "2"},
+			want:   []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChangedKeys(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChangedKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryAndRollback(t *testing.T) {
+	c := &Configuration{Description: "test", Items: map[string]string{"server": "dev"}}
+	Configurations = map[string]*Configuration{"default": c}
+	CurrentConfiguration = c
+	ProfileName = "default"
+
+	snapshotConfigurations()
+
+	// Make two changes, recording history after each one as Save() would.
+	Set("server", "staging")
+	recordHistory()
+	snapshotConfigurations()
+
+	Set("server", "prod")
+	recordHistory()
+	snapshotConfigurations()
+
+	history := History()
+	if len(history) != 2 {
+		t.Fatalf("History() length = %d, want 2", len(history))
+	}
+
+	if got := history[0].Items["server"]; got != "staging" {
+		t.Errorf("History()[0] server = %s, want staging", got)
+	}
+
+	if got := history[1].Items["server"]; got != "dev" {
+		t.Errorf("History()[1] server = %s, want dev", got)
+	}
+
+	if err := Rollback(3); err == nil {
+		t.Errorf("Rollback(3) expected error")
+	}
+
+	if err := Rollback(2); err != nil {
+		t.Fatalf("Rollback(2) unexpected error %v", err)
+	}
+
+	if got := Get("server"); got != "dev" {
+		t.Errorf("Get() after rollback = %s, want dev", got)
+	}
+
+	// The rolled-back values are themselves recorded as history.
+	recordHistory()
+
+	if got := History()[0].Items["server"]; got != "prod" {
+		t.Errorf("History()[0] after rollback server = %s, want prod", got)
+	}
+
+	// The history is trimmed to the configured depth.
+	DefaultHistoryDepth = 1
+	defer func() { DefaultHistoryDepth = 10 }()
+
+	recordHistory()
+
+	if len(History()) != 1 {
+		t.Errorf("History() length after trim = %d, want 1", len(History()))
+	}
+}
@@ -0,0 +1,165 @@
+package settings
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tucats/gopackages/errors"
+)
+
+// Store is the interface implemented by a profile storage backend. The
+// settings package reads the configurations for an application from the
+// active store when the profile is loaded, and writes them back to the
+// store when the profile is saved. An application can supply its own
+// implementation using SetStore().
+type Store interface {
+	// Load reads the configurations for the named application. The result
+	// is a map keyed by the configuration name.
+	Load(application string) (map[string]*Configuration, error)
+
+	// Save writes the configurations for the named application, replacing
+	// any previously stored configurations for that application.
+	Save(application string, configurations map[string]*Configuration) error
+}
+
+// FileStore is a Store that keeps the configurations for each application
+// in a JSON file named for the application. This is the default store.
+type FileStore struct {
+	// Directory is the path of the directory that contains the profile
+	// files. If empty, the ProfileDirectory in the user's home directory
+	// is used.
+	Directory string
+}
+
+// MemoryStore is a Store that keeps the configurations for each application
+// in memory. Nothing is written to disk, so this is useful for testing, or
+// for applications that should not have a persistent profile.
+type MemoryStore struct {
+	profiles map[string][]byte
+	mutex    sync.Mutex
+}
+
+// store is the active storage backend for profiles.
+var store Store = &FileStore{}
+
+// SetStore sets the storage backend used to load and save profiles. This
+// must be done before the profile is loaded. The previous store is returned
+// so the caller can restore it later. If the store is nil, the default file
+// store is used.
+func SetStore(s Store) Store {
+	previous := store
+
+	if s == nil {
+		s = &FileStore{}
+	}
+
+	store = s
+
+	return previous
+}
+
+// NewMemoryStore creates a new, empty in-memory profile store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{profiles: map[string][]byte{}}
+}
+
+// Load reads the configurations for the named application from the
+// application's JSON profile file.
+func (f *FileStore) Load(application string) (map[string]*Configuration, error) {
+	path, err := f.path(application)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	configurations := map[string]*Configuration{}
+
+	if err = json.Unmarshal(b, &configurations); err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	return configurations, nil
+}
+
+// Save writes the configurations for the named application to the
+// application's JSON profile file. The directory is created if needed.
+func (f *FileStore) Save(application string, configurations map[string]*Configuration) error {
+	path, err := f.path(application)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), securePermission); err != nil {
+		return errors.NewError(err)
+	}
+
+	b, _ := json.MarshalIndent(configurations, "", "  ")
+
+	if err = os.WriteFile(path, b, securePermission); err != nil {
+		return errors.NewError(err)
+	}
+
+	return nil
+}
+
+// path returns the full path of the profile file for an application.
+func (f *FileStore) path(application string) (string, error) {
+	directory := f.Directory
+
+	if directory == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.NewError(err)
+		}
+
+		directory = filepath.Join(home, ProfileDirectory)
+	}
+
+	return filepath.Join(directory, application+".json"), nil
+}
+
+// Load reads the configurations for the named application from memory. It
+// is an error if nothing has been saved for the application.
+func (m *MemoryStore) Load(application string) (map[string]*Configuration, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	b, found := m.profiles[application]
+	if !found {
+		return nil, errors.ErrNoSuchProfile.Context(application)
+	}
+
+	// The configurations are stored as JSON so the caller always gets
+	// a copy that does not share maps with the stored values.
+	configurations := map[string]*Configuration{}
+	if err := json.Unmarshal(b, &configurations); err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	return configurations, nil
+}
+
+// Save stores a copy of the configurations for the named application.
+func (m *MemoryStore) Save(application string, configurations map[string]*Configuration) error {
+	b, err := json.Marshal(configurations)
+	if err != nil {
+		return errors.NewError(err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.profiles == nil {
+		m.profiles = map[string][]byte{}
+	}
+
+	m.profiles[application] = b
+
+	return nil
+}
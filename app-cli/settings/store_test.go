@@ -0,0 +1,61 @@
+package settings
+
+import (
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{
+			name:  "memory store",
+			store: NewMemoryStore(),
+		},
+		{
+			name:  "file store",
+			store: &FileStore{Directory: t.TempDir()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := SetStore(tt.store)
+			defer SetStore(previous)
+
+			// Nothing has been saved yet, so the load fails but still
+			// results in a usable default configuration.
+			if err := Load("test-app", "default"); err == nil {
+				t.Errorf("Load() of missing profile did not return an error")
+			}
+
+			Set("test.key", "test value")
+
+			if err := Save(); err != nil {
+				t.Fatalf("Save() unexpected error %v", err)
+			}
+
+			if err := Load("test-app", "default"); err != nil {
+				t.Fatalf("Load() unexpected error %v", err)
+			}
+
+			if got := CurrentConfiguration.Items["test.key"]; got != "test value" {
+				t.Errorf("Load() got %q, want %q", got, "test value")
+			}
+
+			if CurrentConfiguration.ID == "" {
+				t.Errorf("Save() did not assign a configuration id")
+			}
+
+			// Loading another application must not see these values.
+			if err := Load("other-app", "default"); err == nil {
+				t.Errorf("Load() of other application did not return an error")
+			}
+
+			if _, found := CurrentConfiguration.Items["test.key"]; found {
+				t.Errorf("Load() of other application found key from test application")
+			}
+		})
+	}
+}
@@ -38,6 +38,16 @@ func (t *Table) ShowRowNumbers(flag bool) *Table {
 	return t
 }
 
+// Localize enables formatting of the numbers, times, and durations passed to
+// AddRowItems using the conventions of the current language, such as "1,234.5"
+// in English or "1.234,5" in German. This is intended for text output; the
+// localized values are strings in JSON output.
+func (t *Table) Localize(flag bool) *Table {
+	t.localize = flag
+
+	return t
+}
+
 // SetMinimumWidth specifies the minimum width of a column. The column number is
 // always zero-based.
 func (t *Table) SetMinimumWidth(n int, w int) error {
@@ -2,8 +2,10 @@ package tables
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/i18n"
 )
 
 // AddRow adds a row to an existing table using an array of string objects,
@@ -35,7 +37,8 @@ func (t *Table) AddRow(row []string) error {
 
 // AddRowItems adds a row to an existing table using individual parameters.
 // Each parameter is converted to a string representation, and the set of all
-// formatted values are added to the table as a row.
+// formatted values are added to the table as a row. If the table is localized,
+// numbers, times, and durations are formatted for the current language.
 func (t *Table) AddRowItems(items ...interface{}) error {
 	if len(items) != t.columnCount {
 		return errors.ErrColumnCount.Context(len(items))
@@ -44,8 +47,29 @@ func (t *Table) AddRowItems(items ...interface{}) error {
 	row := make([]string, t.columnCount)
 
 	for n, item := range items {
-		row[n] = fmt.Sprintf("%v", item)
+		if t.localize {
+			row[n] = localizeItem(item)
+		} else {
+			row[n] = fmt.Sprintf("%v", item)
+		}
 	}
 
 	return t.AddRow(row)
 }
+
+// localizeItem formats a row item using the conventions of the current
+// language.
+func localizeItem(item interface{}) string {
+	switch actual := item.(type) {
+	case time.Time:
+		return i18n.FormatDateTime(actual)
+
+	case time.Duration:
+		return i18n.FormatDuration(actual)
+
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return i18n.FormatNumber(actual)
+	}
+
+	return fmt.Sprintf("%v", item)
+}
@@ -3,8 +3,10 @@ package tables
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/i18n"
 )
 
 func TestTable_SortRows(t *testing.T) {
@@ -321,4 +323,22 @@ func TestTable_AddRowItems(t *testing.T) {
 			t.Errorf("Table.AddRowItems() got %v, want %v", tb.rows, expected)
 		}
 	})
+
+	t.Run("Add localized row items", func(t *testing.T) {
+		savedLanguage := i18n.Language
+		defer func() { i18n.Language = savedLanguage }()
+
+		i18n.Language = "de"
+
+		tb, _ := New([]string{"Count", "Ratio", "When", "Elapsed", "Name"})
+		tb.Localize(true)
+		_ = tb.AddRowItems(12345, 1234.5, time.Date(2024, 3, 7, 14, 5, 0, 0, time.UTC), 90*time.Minute, "Tom")
+
+		expected := [][]string{
+			{"12.345", "1.234,5", "07.03.2024 14:05:00", "1 Stunde, 30 Minuten", "Tom"},
+		}
+		if !reflect.DeepEqual(tb.rows, expected) {
+			t.Errorf("Table.AddRowItems() got %v, want %v", tb.rows, expected)
+		}
+	})
 }
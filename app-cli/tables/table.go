@@ -44,6 +44,7 @@ type Table struct {
 	showUnderlines bool
 	showHeadings   bool
 	showRowNumbers bool
+	localize       bool
 }
 
 // New creates a new table object, given a list of headings.
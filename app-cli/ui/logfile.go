@@ -1,35 +1,66 @@
 package ui
 
 import (
-	"io/ioutil"
+	"compress/gzip"
+	"fmt"
+	"io"
 	"os"
-	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/tucats/gopackages/errors"
 )
 
 var logFile *os.File
+var logSize int64
+var logWithTimeStamp bool
 var baseLogFileName string
 var currentLogFileName string
 
+// The mutex serializes writing to the log file and rotating it.
+var logMutex sync.Mutex
+
 // LogRetainCount is the number of roll-over log versions to keep in the
 // logging directory.
 var LogRetainCount = -1
 
+// LogMaxSize is the size, in bytes, at which the log file is rolled over.
+// If zero, the log is not rolled over based on its size.
+var LogMaxSize int64
+
+// LogMaxAge is the age after which a roll-over log version is deleted, even
+// if fewer than LogRetainCount versions exist. If zero, versions are not
+// deleted based on their age.
+var LogMaxAge time.Duration
+
+// LogCompress indicates if roll-over log versions are compressed with gzip.
+var LogCompress bool
+
+// OpenLogFile starts writing log messages to the named file. If withTimeStamp
+// is true, the name of the file includes the time it was created, and it is
+// rolled over each day. In either case, the file is rolled over when it grows
+// past LogMaxSize.
 func OpenLogFile(userLogFileName string, withTimeStamp bool) error {
 	if LogRetainCount < 1 {
 		LogRetainCount = 3
 	}
 
+	_ = SaveLastLog()
+
+	logMutex.Lock()
 	err := openLogFile(userLogFileName, withTimeStamp)
+	logMutex.Unlock()
+
 	if err != nil {
 		return errors.NewError(err)
 	}
 
+	WriteLog(InfoLogger, "New log file opened: %s", currentLogFileName)
+
 	if withTimeStamp {
 		PurgeLogs()
 
@@ -48,12 +79,11 @@ func CurrentLogFile() string {
 	return currentLogFileName
 }
 
-// Internal routine that actually opens a log file.
+// Internal routine that actually opens a log file. The caller must hold the
+// log mutex, and any previous log file must already be closed.
 func openLogFile(path string, withTimeStamp bool) error {
 	var err error
 
-	_ = SaveLastLog()
-
 	var fileName string
 
 	if withTimeStamp {
@@ -65,21 +95,63 @@ func openLogFile(path string, withTimeStamp bool) error {
 		}
 	}
 
-	logFile, err = os.Create(fileName)
+	logFile, err = os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		logFile = nil
 
 		return errors.NewError(err)
 	}
 
+	logSize = 0
+	logWithTimeStamp = withTimeStamp
 	baseLogFileName, _ = filepath.Abs(path)
 	currentLogFileName, _ = filepath.Abs(fileName)
 
-	WriteLog(InfoLogger, "New log file opened: %s", currentLogFileName)
-
 	return nil
 }
 
+// writeLogFile writes a line to the log file, rolling the file over first if
+// the line would make it larger than LogMaxSize. The result is false if there
+// is no log file.
+func writeLogFile(line string) (bool, error) {
+	logMutex.Lock()
+
+	if logFile == nil {
+		logMutex.Unlock()
+
+		return false, nil
+	}
+
+	var rotated string
+
+	var err error
+
+	if LogMaxSize > 0 && logSize > 0 && logSize+int64(len(line)) > LogMaxSize {
+		if rotated, err = rotateLogFile(); err != nil {
+			reportRollOverError(err)
+
+			// If the log file could not be reopened either, the line is
+			// written to stderr by the caller.
+			if logFile == nil {
+				logMutex.Unlock()
+
+				return false, nil
+			}
+		}
+	}
+
+	n, err := logFile.Write([]byte(line))
+	logSize += int64(n)
+
+	logMutex.Unlock()
+
+	if rotated != "" {
+		finishRollOver(rotated)
+	}
+
+	return true, err
+}
+
 // Schedule roll-over operations for the log. We calculate when the next start-of-date + 24 hours
 // is, and sleep until then. We then roll over the log file and sleep again.
 func rollOverTask() {
@@ -97,38 +169,168 @@ func rollOverTask() {
 // Roll over the open log. Close the current log, and rename it to include a timestamp of when
 // it was created. Then create a new log file.
 func RollOverLog() {
-	if err1 := SaveLastLog(); err1 != nil {
-		WriteLog(InternalLogger, "ERROR: RollOverLog() unable to roll over log file; %v", err1)
+	logMutex.Lock()
+
+	if logFile == nil {
+		logMutex.Unlock()
 
 		return
 	}
 
-	err := openLogFile(baseLogFileName, true)
+	rotated, err := rotateLogFile()
 	if err != nil {
-		WriteLog(InternalLogger, "ERROR: RollOverLog() unable to open new log file; %v", err)
+		reportRollOverError(err)
+	}
+
+	logMutex.Unlock()
 
+	if err != nil {
 		return
 	}
 
+	finishRollOver(rotated)
+}
+
+// rotateLogFile closes the current log file and opens a new one. A log file
+// without a time stamp in its name is renamed to include one first. The new
+// file starts with a message naming the file that was closed, and that name
+// is returned. The caller must hold the log mutex.
+//
+// If the file cannot be renamed or the new file cannot be opened, the file
+// that was closed is opened again, so messages are still written to it, and
+// the next roll over is not attempted until it has grown by LogMaxSize again.
+// The log file is nil only if that fails too.
+func rotateLogFile() (string, error) {
+	rotated := currentLogFileName
+
+	logFile.Close()
+	logFile = nil
+
+	if !logWithTimeStamp {
+		rotated = timeStampLogFileName(baseLogFileName)
+
+		if err := os.Rename(currentLogFileName, rotated); err != nil {
+			return "", reopenLogFile(currentLogFileName, errors.NewError(err))
+		}
+	}
+
+	if err := openLogFile(baseLogFileName, logWithTimeStamp); err != nil {
+		return "", reopenLogFile(rotated, err)
+	}
+
+	// The notice is written directly, since the caller holds the mutex.
+	notice := formatLogEntry(InfoLogger, InfoLevel, nil, "Log file rolled over from "+rotated) + "\n"
+	n, _ := logFile.Write([]byte(notice))
+	logSize += int64(n)
+
+	return rotated, nil
+}
+
+// reopenLogFile appends to a log file again after it could not be rolled
+// over because of the given error, which is returned along with any error
+// reopening the file. The caller must hold the log mutex.
+func reopenLogFile(fileName string, err error) error {
+	file, openError := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if openError != nil {
+		return errors.Join(err, openError)
+	}
+
+	logFile = file
+	logSize = 0
+	currentLogFileName = fileName
+
+	return err
+}
+
+// reportRollOverError writes an error rolling over the log file to stderr,
+// since it may not be possible to write it to the log.
+func reportRollOverError(err error) {
+	fmt.Fprintf(os.Stderr, "ERROR: unable to roll over log file; %v\n", err)
+}
+
+// finishRollOver compresses the log file that was rolled over, if requested,
+// and deletes the roll-over versions that are no longer retained.
+func finishRollOver(rotated string) {
+	if LogCompress {
+		if err := compressLogFile(rotated); err != nil {
+			WriteLog(InternalLogger, "ERROR: unable to compress log file %s; %v", rotated, err)
+		}
+	}
+
 	PurgeLogs()
 }
 
+// compressLogFile replaces a log file with a gzip compressed copy whose name
+// has the ".gz" suffix.
+func compressLogFile(fileName string) error {
+	input, err := os.Open(fileName)
+	if err != nil {
+		return errors.NewError(err)
+	}
+
+	defer input.Close()
+
+	output, err := os.OpenFile(fileName+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.NewError(err)
+	}
+
+	writer := gzip.NewWriter(output)
+	writer.Name = filepath.Base(fileName)
+
+	_, err = io.Copy(writer, input)
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(fileName + ".gz")
+
+		return errors.NewError(err)
+	}
+
+	return os.Remove(fileName)
+}
+
+// timeStampLogFileName returns the name of a log file that includes the current
+// time. If a log file already has that name, a counter is added to it.
 func timeStampLogFileName(path string) string {
 	logStarted := time.Now()
 	dateStamp := logStarted.Format("_2006-01-02-150405")
-	newName, _ := filepath.Abs(strings.TrimSuffix(path, ".log") + dateStamp + ".log")
+	baseName := strings.TrimSuffix(path, ".log") + dateStamp
+
+	newName, _ := filepath.Abs(baseName + ".log")
+
+	for count := 1; logFileExists(newName); count++ {
+		newName, _ = filepath.Abs(baseName + "-" + strconv.Itoa(count) + ".log")
+	}
 
 	return newName
 }
 
+func logFileExists(fileName string) bool {
+	for _, name := range []string{fileName, fileName + ".gz"} {
+		if _, err := os.Stat(name); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Save the current (last) log file to the archive name with the timestamp of when the log
 // was initialized.
 func SaveLastLog() error {
 	if logFile != nil {
 		WriteLog(InfoLogger, "Log file being rolled over")
 
-		sequenceMux.Lock()
-		defer sequenceMux.Unlock()
+		logMutex.Lock()
+		defer logMutex.Unlock()
+
 		logFile.Close()
 
 		logFile = nil
@@ -137,45 +339,81 @@ func SaveLastLog() error {
 	return nil
 }
 
+// PurgeLogs deletes the roll-over versions of the log file, except for the most
+// recent LogRetainCount versions. Versions older than LogMaxAge are deleted even
+// if they would otherwise be retained. The versions are the files in the log
+// directory whose names are the log file name followed by a time stamp. The
+// number of files deleted is returned.
 func PurgeLogs() int {
 	count := 0
 	keep := LogRetainCount
-	searchPath := path.Dir(CurrentLogFile())
-	names := []string{}
+	searchPath := filepath.Dir(baseLogFileName)
+	prefix := strings.TrimSuffix(filepath.Base(baseLogFileName), ".log") + "_"
 
 	Log(ServerLogger, "Purging all but %d logs from %s", keep, searchPath)
 
-	files, err := ioutil.ReadDir(searchPath)
+	files, err := os.ReadDir(searchPath)
 	if err != nil {
 		Log(ServerLogger, "Error making list of log files, %s", err.Error())
 
 		return count
 	}
 
+	type version struct {
+		name     string
+		modified time.Time
+	}
+
+	versions := []version{}
+
 	for _, file := range files {
-		if strings.HasPrefix(file.Name(), "ego-server_") && !file.IsDir() {
-			names = append(names, file.Name())
+		fileName := filepath.Join(searchPath, file.Name())
+		if file.IsDir() || fileName == currentLogFileName || !isLogVersion(file.Name(), prefix) {
+			continue
 		}
-	}
 
-	if len(names) <= 1 {
-		return 0
+		if info, err := file.Info(); err == nil {
+			versions = append(versions, version{name: fileName, modified: info.ModTime()})
+		}
 	}
 
-	sort.Strings(names)
+	// Sort the versions from newest to oldest.
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].modified.Equal(versions[j].modified) {
+			return versions[i].name > versions[j].name
+		}
+
+		return versions[i].modified.After(versions[j].modified)
+	})
 
-	for n := 0; n < len(names)-keep; n++ {
-		name := names[n]
-		fileName := path.Join(searchPath, name)
+	for n, v := range versions {
+		expired := LogMaxAge > 0 && time.Since(v.modified) > LogMaxAge
+		if n < keep && !expired {
+			continue
+		}
 
-		err := os.Remove(fileName)
-		if err != nil {
+		if err := os.Remove(v.name); err != nil {
 			Log(ServerLogger, "Error purging log file, %v", err)
 		} else {
-			Log(ServerLogger, "Purged log file %s", fileName)
+			Log(ServerLogger, "Purged log file %s", v.name)
 			count++
 		}
 	}
 
 	return count
 }
+
+// isLogVersion reports whether a file name is a roll-over version of a log,
+// which is the prefix followed by a time stamp, and the ".log" suffix or the
+// ".log.gz" suffix of a compressed version.
+func isLogVersion(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+		return false
+	}
+
+	if c := name[len(prefix)]; c < '0' || c > '9' {
+		return false
+	}
+
+	return strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")
+}
@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogRotation(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "daemon.log")
+
+	// A file from another log in the same directory is never purged.
+	other := filepath.Join(dir, "other_2020-01-01-000000.log")
+	_ = os.WriteFile(other, []byte("other"), 0644)
+
+	LogMaxSize = 500
+	LogRetainCount = 2
+	LogCompress = true
+
+	// Restore the message sequence number, which other tests depend on.
+	savedSequence := sequence
+
+	defer func() {
+		_ = SaveLastLog()
+		sequence = savedSequence
+		LogMaxSize = 0
+		LogRetainCount = -1
+		LogCompress = false
+	}()
+
+	if err := OpenLogFile(base, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for n := 0; n < 30; n++ {
+		WriteLog(UserLogger, "message number %d with some padding text", n)
+	}
+
+	info, err := os.Stat(base)
+	if err != nil {
+		t.Fatalf("current log file missing: %v", err)
+	}
+
+	if info.Size() > LogMaxSize {
+		t.Errorf("current log file size %d is larger than %d", info.Size(), LogMaxSize)
+	}
+
+	versions, _ := filepath.Glob(filepath.Join(dir, "daemon_*.log.gz"))
+	if len(versions) != LogRetainCount {
+		t.Errorf("found %d compressed versions, want %d", len(versions), LogRetainCount)
+	}
+
+	uncompressed, _ := filepath.Glob(filepath.Join(dir, "daemon_*.log"))
+	if len(uncompressed) != 0 {
+		t.Errorf("found uncompressed versions %v", uncompressed)
+	}
+
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("log file of another application was purged")
+	}
+}
+
+func TestLogRotationFailure(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "daemon.log")
+
+	LogMaxSize = 500
+
+	savedSequence := sequence
+	savedStderr := os.Stderr
+
+	defer func() {
+		os.Stderr = savedStderr
+		_ = SaveLastLog()
+		sequence = savedSequence
+		LogMaxSize = 0
+		LogRetainCount = -1
+	}()
+
+	if err := OpenLogFile(base, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Once the open log file is deleted, it cannot be renamed when it is
+	// rolled over.
+	_ = os.Remove(base)
+
+	stderr, err := os.Create(filepath.Join(dir, "stderr.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	os.Stderr = stderr
+
+	for n := 0; n < 10; n++ {
+		WriteLog(UserLogger, "message number %d with some padding text", n)
+	}
+
+	os.Stderr = savedStderr
+	stderr.Close()
+
+	if CurrentLogFile() != base {
+		t.Fatalf("log file is %q after a failed roll over, want %q", CurrentLogFile(), base)
+	}
+
+	b, _ := os.ReadFile(base)
+	if !strings.Contains(string(b), "message number 9 ") {
+		t.Errorf("messages were not written to the log file after a failed roll over:\n%s", b)
+	}
+
+	b, _ = os.ReadFile(stderr.Name())
+	if !strings.Contains(string(b), "unable to roll over log file") {
+		t.Errorf("roll over error was not reported to stderr: %q", b)
+	}
+}
+
+func TestPurgeLogsByAge(t *testing.T) {
+	dir := t.TempDir()
+	baseLogFileName = filepath.Join(dir, "daemon.log")
+	currentLogFileName = baseLogFileName
+
+	old := filepath.Join(dir, "daemon_2020-01-01-000000.log")
+	recent := filepath.Join(dir, "daemon_2020-01-02-000000.log.gz")
+
+	for _, name := range []string{old, recent} {
+		_ = os.WriteFile(name, []byte("text"), 0644)
+	}
+
+	_ = os.Chtimes(old, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour))
+
+	LogRetainCount = 10
+	LogMaxAge = 24 * time.Hour
+
+	defer func() {
+		LogRetainCount = -1
+		LogMaxAge = 0
+	}()
+
+	if count := PurgeLogs(); count != 1 {
+		t.Errorf("purged %d files, want 1", count)
+	}
+
+	if _, err := os.Stat(old); err == nil {
+		t.Errorf("old log version was not purged")
+	}
+
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("recent log version was purged")
+	}
+}
+
+func TestIsLogVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"daemon_2024-01-01-120000.log", true},
+		{"daemon_2024-01-01-120000-1.log.gz", true},
+		{"daemon_server_2024-01-01-120000.log", false},
+		{"daemon.log", false},
+		{"daemon_2024-01-01-120000.txt", false},
+	}
+
+	for _, tt := range tests {
+		if got := isLogVersion(tt.name, "daemon_"); got != tt.want {
+			t.Errorf("isLogVersion(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !strings.HasSuffix(timeStampLogFileName("/tmp/x.log"), ".log") {
+		t.Errorf("time stamp log file name does not end with .log")
+	}
+}
@@ -0,0 +1,237 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tucats/gopackages/errors"
+)
+
+// Log levels, from the most to the least severe. A logger writes a message
+// only if the logger is active and the message level is at or above the
+// severity of the logger's level. Messages written with Log() or LogWith()
+// are at InfoLevel.
+const (
+	ErrorLevel = iota
+	WarnLevel
+	InfoLevel
+	DebugLevel
+	TraceLevel
+)
+
+// The names of the log levels, in the order of the level values.
+var levelNames = []string{"error", "warn", "info", "debug", "trace"}
+
+// LogFormat is the format of log messages, either TextFormat or JSONFormat.
+// In JSON format, each message is written as a single line JSON object.
+var LogFormat = TextFormat
+
+// Fields are structured values added to a log message. In text format, they
+// are written after the message as name=value pairs. In JSON format, they
+// are written as the "fields" object.
+type Fields map[string]interface{}
+
+// logEntry is a log message written in JSON format.
+type logEntry struct {
+	Time     string `json:"time"`
+	Sequence int    `json:"seq"`
+	Class    string `json:"class"`
+	Level    string `json:"level"`
+	Message  string `json:"msg"`
+	Session  int    `json:"session,omitempty"`
+	Fields   Fields `json:"fields,omitempty"`
+}
+
+// SetLogFormat sets the format of log messages, which must be "text" or
+// "json".
+func SetLogFormat(format string) error {
+	switch strings.ToLower(format) {
+	case TextFormat:
+		LogFormat = TextFormat
+
+	case JSONFormat:
+		LogFormat = JSONFormat
+
+	default:
+		return errors.ErrInvalidOutputFormat.Context(format)
+	}
+
+	return nil
+}
+
+// LevelByName returns the log level with the given name, or -1 if there is
+// no such level.
+func LevelByName(name string) int {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(levelName, name) {
+			return level
+		}
+	}
+
+	return -1
+}
+
+// LevelName returns the name of a log level.
+func LevelName(level int) string {
+	if level < 0 || level >= len(levelNames) {
+		return strconv.Itoa(level)
+	}
+
+	return levelNames[level]
+}
+
+// SetLevel sets the least severe level of the messages written by a logger.
+func SetLevel(class int, level int) bool {
+	if class < 0 || class >= len(loggers) || level < ErrorLevel || level > TraceLevel {
+		WriteLog(InternalLogger, "ERROR: Invalid SetLevel() class %d, level %d", class, level)
+
+		return false
+	}
+
+	loggers[class].level = level
+
+	return true
+}
+
+// Level returns the least severe level of the messages written by a logger.
+func Level(class int) int {
+	if class < 0 || class >= len(loggers) {
+		WriteLog(InternalLogger, "ERROR: Invalid Level() class %d", class)
+
+		return -1
+	}
+
+	return loggers[class].level
+}
+
+// IsLevelActive reports whether a message at the given level would be written
+// by a logger.
+func IsLevelActive(class int, level int) bool {
+	return IsActive(class) && level <= loggers[class].level
+}
+
+// LogAt writes a message at the given level, if the logger is active and its
+// level includes the message.
+func LogAt(class int, level int, format string, args ...interface{}) {
+	if class < 0 || class >= len(loggers) {
+		WriteLog(InternalLogger, "ERROR: Invalid LogAt() class %d", class)
+
+		return
+	}
+
+	if IsLevelActive(class, level) {
+		writeLogEntry(class, level, nil, fmt.Sprintf(format, args...))
+	}
+}
+
+// LogWith writes a message with structured fields, if the logger is active.
+// The message is at InfoLevel. A "session" field is reported as the session
+// ID of the message.
+func LogWith(class int, fields Fields, format string, args ...interface{}) {
+	if class < 0 || class >= len(loggers) {
+		WriteLog(InternalLogger, "ERROR: Invalid LogWith() class %d", class)
+
+		return
+	}
+
+	if IsLevelActive(class, InfoLevel) {
+		writeLogEntry(class, InfoLevel, fields, fmt.Sprintf(format, args...))
+	}
+}
+
+// formatJSONLogEntry formats a log message as a single line of JSON. The
+// session ID is taken from the "session" field, or from a "[n] " prefix on
+// the message, which is how the server loggers identify requests.
+func formatJSONLogEntry(stamp time.Time, sequence int, class, level int, fields Fields, msg string) string {
+	entry := logEntry{
+		Time:     stamp.Format(time.RFC3339Nano),
+		Sequence: sequence,
+		Class:    loggers[class].name,
+		Level:    LevelName(level),
+		Message:  msg,
+		Session:  messageSession(msg),
+	}
+
+	if len(fields) > 0 {
+		entry.Fields = Fields{}
+
+		for name, value := range fields {
+			if name == "session" {
+				if session, err := strconv.Atoi(fmt.Sprintf("%v", value)); err == nil {
+					entry.Session = session
+
+					continue
+				}
+			}
+
+			entry.Fields[name] = value
+		}
+
+		if len(entry.Fields) == 0 {
+			entry.Fields = nil
+		}
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		// A field value that can't be encoded is written as text instead.
+		for name, value := range entry.Fields {
+			entry.Fields[name] = fmt.Sprintf("%v", value)
+		}
+
+		b, _ = json.Marshal(entry)
+	}
+
+	return string(b)
+}
+
+// formatFields formats the fields as name=value pairs, sorted by name, for a
+// text log message.
+func formatFields(fields Fields) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	b := strings.Builder{}
+
+	for _, name := range names {
+		value := fmt.Sprintf("%v", fields[name])
+		if value == "" || strings.ContainsAny(value, " \t\"=") {
+			value = strconv.Quote(value)
+		}
+
+		b.WriteString(" ")
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(value)
+	}
+
+	return b.String()
+}
+
+// messageSession returns the session ID from a "[n] " prefix on a message, or
+// zero if there isn't one.
+func messageSession(msg string) int {
+	if !strings.HasPrefix(msg, "[") {
+		return 0
+	}
+
+	text, _, found := strings.Cut(msg[1:], "] ")
+	if !found {
+		return 0
+	}
+
+	session, err := strconv.Atoi(text)
+	if err != nil {
+		return 0
+	}
+
+	return session
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLogEntry(t *testing.T) {
+	stamp := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	server := LoggerByName("SERVER")
+
+	tests := []struct {
+		name   string
+		level  int
+		fields Fields
+		msg    string
+		want   logEntry
+	}{
+		{
+			name:  "simple message",
+			level: InfoLevel,
+			msg:   "string text",
+			want:  logEntry{Class: "SERVER", Level: "info", Message: "string text"},
+		},
+		{
+			name:  "session prefix",
+			level: DebugLevel,
+			msg:   "[42] GET /services/up/",
+			want:  logEntry{Class: "SERVER", Level: "debug", Message: "[42] GET /services/up/", Session: 42},
+		},
+		{
+			name:   "fields and session field",
+			level:  WarnLevel,
+			fields: Fields{"session": 7, "status": 404},
+			msg:    "not found",
+			want:   logEntry{Class: "SERVER", Level: "warn", Message: "not found", Session: 7, Fields: Fields{"status": float64(404)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := formatJSONLogEntry(stamp, 3, server, tt.level, tt.fields, tt.msg)
+
+			got := logEntry{}
+			if err := json.Unmarshal([]byte(text), &got); err != nil {
+				t.Fatalf("invalid JSON %s: %v", text, err)
+			}
+
+			tt.want.Time = "2024-03-01T12:30:00Z"
+			tt.want.Sequence = 3
+
+			gotText, _ := json.Marshal(got)
+			wantText, _ := json.Marshal(tt.want)
+
+			if string(gotText) != string(wantText) {
+				t.Errorf("got %s, want %s", gotText, wantText)
+			}
+
+			if !lineHasSession(text, tt.want.Session) && tt.want.Session > 0 {
+				t.Errorf("session %d not found in %s", tt.want.Session, text)
+			}
+		})
+	}
+}
+
+func TestLogFields(t *testing.T) {
+	got := formatLogEntry(LoggerByName("USER"), InfoLevel, Fields{"b": "two words", "a": 1}, "text")
+
+	if want := ": text a=1 b=\"two words\""; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	class := LoggerByName("TABLES")
+	defer Active(class, false)
+	defer SetLevel(class, InfoLevel)
+
+	Active(class, true)
+
+	tests := []struct {
+		level   int
+		message int
+		want    bool
+	}{
+		{level: InfoLevel, message: InfoLevel, want: true},
+		{level: InfoLevel, message: DebugLevel, want: false},
+		{level: WarnLevel, message: InfoLevel, want: false},
+		{level: WarnLevel, message: ErrorLevel, want: true},
+		{level: TraceLevel, message: TraceLevel, want: true},
+	}
+
+	for _, tt := range tests {
+		SetLevel(class, tt.level)
+
+		if got := IsLevelActive(class, tt.message); got != tt.want {
+			t.Errorf("level %s, message %s: got %v, want %v", LevelName(tt.level), LevelName(tt.message), got, tt.want)
+		}
+	}
+
+	if LevelByName("Debug") != DebugLevel || LevelByName("verbose") != -1 {
+		t.Errorf("LevelByName() returned unexpected values")
+	}
+
+	if err := SetLogFormat("yaml"); err == nil {
+		t.Errorf("SetLogFormat() accepted an invalid format")
+	}
+}
@@ -5,6 +5,7 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -75,29 +76,30 @@ const (
 type logger struct {
 	name   string
 	active bool
+	level  int
 }
 
 // The order of these items must match the numeric values of the logger classses above.
 var loggers []logger = []logger{
-	{"APP", false},
-	{"AUTH", false},
-	{"BYTECODE", false},
-	{"CLI", false},
-	{"COMPILER", false},
-	{"DB", false},
-	{"DEBUG", false},
-	{"INFO", false},
-	{"INTERNAL", true},
-	{"OPTIMIZER", false},
-	{"REST", false},
-	{"SERVER", false},
-	{"SQL", false},
-	{"STATS", false},
-	{"SYMBOLS", false},
-	{"TABLES", false},
-	{"TRACE", false},
-	{"TOKEN", false},
-	{"USER", false},
+	{"APP", false, InfoLevel},
+	{"AUTH", false, InfoLevel},
+	{"BYTECODE", false, InfoLevel},
+	{"CLI", false, InfoLevel},
+	{"COMPILER", false, InfoLevel},
+	{"DB", false, InfoLevel},
+	{"DEBUG", false, InfoLevel},
+	{"INFO", false, InfoLevel},
+	{"INTERNAL", true, InfoLevel},
+	{"OPTIMIZER", false, InfoLevel},
+	{"REST", false, InfoLevel},
+	{"SERVER", false, InfoLevel},
+	{"SQL", false, InfoLevel},
+	{"STATS", false, InfoLevel},
+	{"SYMBOLS", false, InfoLevel},
+	{"TABLES", false, InfoLevel},
+	{"TRACE", false, InfoLevel},
+	{"TOKEN", false, InfoLevel},
+	{"USER", false, InfoLevel},
 }
 
 // LogTimeStampFormat stores the format string used to produce log messages,
@@ -106,6 +108,8 @@ var loggers []logger = []logger{
 var LogTimeStampFormat string
 
 // DefineLogger creates a new logger that can be used by the program.
+// The logger writes messages at InfoLevel and above until SetLevel()
+// is used to change its level.
 // The logger name must be unique. The return value is the logger id
 // passed to subsequent calls to ui.Log(loggerId, msg...) calls to
 // generate log output. This must be done in the main program before
@@ -120,7 +124,7 @@ func DefineLogger(name string, active bool) int {
 		}
 	}
 
-	loggers = append(loggers, logger{name: name, active: active})
+	loggers = append(loggers, logger{name: name, active: active, level: InfoLevel})
 
 	return len(loggers) - 1
 }
@@ -205,7 +209,8 @@ func IsActive(class int) bool {
 // Log displays a message if the selected log class is enabled. If the
 // class is not active, no action is taken.  Use WriteLog if you want
 // to write a message to a logging class regardless of whether it is
-// active or not.
+// active or not. The message is at InfoLevel, so it is not written if
+// the logger level is set to WarnLevel or ErrorLevel.
 func Log(class int, format string, args ...interface{}) {
 	if class < 0 || class >= len(loggers) {
 		WriteLog(InternalLogger, "ERROR: Invalid Debug() class %d", class)
@@ -213,14 +218,15 @@ func Log(class int, format string, args ...interface{}) {
 		return
 	}
 
-	if loggers[class].active {
+	if loggers[class].active && loggers[class].level >= InfoLevel {
 		WriteLog(class, format, args...)
 	}
 }
 
 // WriteLog displays a message to the log, regardless of whether the
-// logger is enabled. If there is an active log file, the message is
-// added to the log file, else it is written to stdout.
+// logger is enabled. The message is added to the active log file and
+// sent to the log sinks. If there is neither a log file nor a sink,
+// it is written to stderr.
 func WriteLog(class int, format string, args ...interface{}) {
 	if class < 0 || class >= len(loggers) {
 		WriteLog(InternalLogger, "ERROR: Invalid Log() class %d", class)
@@ -228,23 +234,35 @@ func WriteLog(class int, format string, args ...interface{}) {
 		return
 	}
 
-	s := formatLogMessage(class, format, args...)
+	writeLogEntry(class, InfoLevel, nil, fmt.Sprintf(format, args...))
+}
 
-	if logFile != nil {
-		_, err := logFile.Write([]byte(s + "\n"))
-		if err != nil {
-			logFile = nil
+// writeLogEntry writes a formatted message to the log file and the log
+// sinks, or to stderr if there are none. Log messages are never written to
+// stdout unless a sink sends them there, so they do not mix with the output
+// of a command.
+func writeLogEntry(class, level int, fields Fields, msg string) {
+	s := formatLogEntry(class, level, fields, msg)
 
-			WriteLog(InternalLogger, "ERROR: Log() unable to write log entry; %v", err)
+	delivered := writeLogSinks(class, level, s)
 
-			return
-		}
-	} else {
-		fmt.Println(s)
+	written, err := writeLogFile(s + "\n")
+	if err != nil {
+		logMutex.Lock()
+		logFile = nil
+		logMutex.Unlock()
+
+		WriteLog(InternalLogger, "ERROR: Log() unable to write log entry; %v", err)
+
+		return
+	}
+
+	if !written && !delivered {
+		fmt.Fprintln(os.Stderr, s)
 	}
 }
 
-// formatLogMessage displays a message to stdout.
+// formatLogMessage formats a message for the log.
 func formatLogMessage(class int, format string, args ...interface{}) string {
 	if class < 0 || class >= len(loggers) {
 		WriteLog(InternalLogger, "ERROR: Invalid LogMessage() class %d", class)
@@ -252,22 +270,36 @@ func formatLogMessage(class int, format string, args ...interface{}) string {
 		return ""
 	}
 
-	className := loggers[class].name
-	s := fmt.Sprintf(format, args...)
+	return formatLogEntry(class, InfoLevel, nil, fmt.Sprintf(format, args...))
+}
+
+// formatLogEntry formats a message with its level and fields, using the
+// current log format. Sensitive values are masked unless RedactLogs is
+// false. Each message is given the next sequence number.
+func formatLogEntry(class, level int, fields Fields, msg string) string {
+	if RedactLogs {
+		msg = Redact(msg)
+		fields = redactFields(fields)
+	}
 
 	sequenceMux.Lock()
 	defer sequenceMux.Unlock()
 
 	sequence = sequence + 1
-	sequenceString := fmt.Sprintf("%d", sequence)
+	now := time.Now()
+
+	if LogFormat == JSONFormat {
+		return formatJSONLogEntry(now, sequence, class, level, fields, msg)
+	}
 
 	if LogTimeStampFormat == "" {
 		LogTimeStampFormat = "2006-01-02 15:04:05"
 	}
 
-	s = fmt.Sprintf("[%s] %-5s %-7s: %s", time.Now().Format(LogTimeStampFormat), sequenceString, className, s)
+	className := loggers[class].name
+	sequenceString := fmt.Sprintf("%d", sequence)
 
-	return s
+	return fmt.Sprintf("[%s] %-5s %-7s: %s", now.Format(LogTimeStampFormat), sequenceString, className, msg) + formatFields(fields)
 }
 
 // Say displays a message to the user unless we are in "quiet" mode.
@@ -279,7 +311,9 @@ func formatLogMessage(class int, format string, args ...interface{}) string {
 // a localization string. If so, it is localized before output.
 // If it was localized, and there is a single argument that is a
 // proper map[string]interface{} object, then that is used for the
-// formatting.
+// formatting. The placeholders in the message can format numbers,
+// dates, and durations for the current language, and choose plural
+// forms, such as {{count|plural one{# row} other{# rows}}}.
 func Say(format string, args ...interface{}) {
 	var s string
 
@@ -292,9 +326,9 @@ func Say(format string, args ...interface{}) {
 			if m, ok := args[0].(map[string]interface{}); ok {
 				format = i18n.T(format, m)
 				alreadyFormatted = true
+			} else {
+				format = i18n.T(format)
 			}
-
-			format = i18n.T(format)
 		} else {
 			format = i18n.T(format)
 		}
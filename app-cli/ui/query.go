@@ -0,0 +1,345 @@
+package ui
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tucats/gopackages/errors"
+)
+
+// LogQuery selects messages from a log. Fields that are not set do not limit
+// the messages selected.
+type LogQuery struct {
+	// The log file to read. If empty, the current log file is used. The
+	// rolled over versions of the file are read as well, oldest first.
+	File string
+
+	// The names of the loggers whose messages are selected.
+	Classes []string
+
+	// The range of times of the messages selected.
+	Since time.Time
+	Until time.Time
+
+	// The range of sequence numbers of the messages selected.
+	FirstSequence int
+	LastSequence  int
+
+	// The session whose messages are selected.
+	Session int
+
+	// A regular expression the message text must match.
+	Pattern string
+
+	// The maximum number of messages returned. If there are more, the most
+	// recent ones are returned.
+	Limit int
+}
+
+// LogRecord is a message read from a log.
+type LogRecord struct {
+	Time     time.Time `json:"time"`
+	Sequence int       `json:"seq"`
+	Class    string    `json:"class"`
+	Level    string    `json:"level,omitempty"`
+	Message  string    `json:"msg"`
+	Session  int       `json:"session,omitempty"`
+	Fields   Fields    `json:"fields,omitempty"`
+}
+
+// QueryLog returns the messages from a log file and its rolled over versions
+// that are selected by the query, oldest first. Messages in both the text and
+// JSON log formats are read. If no file is given and there is no log file, the
+// messages are taken from the first ring buffer sink.
+func QueryLog(query LogQuery) ([]LogRecord, error) {
+	var pattern *regexp.Regexp
+
+	if query.Pattern != "" {
+		var err error
+
+		if pattern, err = regexp.Compile(query.Pattern); err != nil {
+			return nil, errors.ErrInvalidValue.Context(query.Pattern)
+		}
+	}
+
+	records := []LogRecord{}
+
+	add := func(record LogRecord) {
+		if query.matches(record, pattern) {
+			records = append(records, record)
+		}
+	}
+
+	fileName := query.File
+	if fileName == "" {
+		fileName = CurrentLogFile()
+	}
+
+	if fileName == "" {
+		ring := ringSink()
+		if ring == nil {
+			return nil, errors.ErrNoLogFile
+		}
+
+		parseLogLines(ring.Lines(len(ring.lines), 0), add)
+	} else {
+		files, err := logVersions(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if err := readLogFile(file, add); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if query.Limit > 0 && len(records) > query.Limit {
+		records = records[len(records)-query.Limit:]
+	}
+
+	return records, nil
+}
+
+// matches reports whether the query selects a record.
+func (q LogQuery) matches(record LogRecord, pattern *regexp.Regexp) bool {
+	if len(q.Classes) > 0 {
+		found := false
+
+		for _, class := range q.Classes {
+			if strings.EqualFold(class, record.Class) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	switch {
+	case !q.Since.IsZero() && record.Time.Before(q.Since):
+		return false
+
+	case !q.Until.IsZero() && record.Time.After(q.Until):
+		return false
+
+	case q.FirstSequence > 0 && record.Sequence < q.FirstSequence:
+		return false
+
+	case q.LastSequence > 0 && record.Sequence > q.LastSequence:
+		return false
+
+	case q.Session > 0 && record.Session != q.Session:
+		return false
+
+	case pattern != nil && !pattern.MatchString(record.Message):
+		return false
+	}
+
+	return true
+}
+
+// logVersions returns the rolled over versions of a log file, oldest first,
+// followed by the file itself if it exists.
+func logVersions(fileName string) ([]string, error) {
+	fileName, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	directory := filepath.Dir(fileName)
+	prefix := strings.TrimSuffix(filepath.Base(fileName), ".log") + "_"
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	type version struct {
+		name     string
+		modified time.Time
+	}
+
+	versions := []version{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isLogVersion(entry.Name(), prefix) {
+			continue
+		}
+
+		if info, err := entry.Info(); err == nil {
+			versions = append(versions, version{name: filepath.Join(directory, entry.Name()), modified: info.ModTime()})
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].modified.Equal(versions[j].modified) {
+			return versions[i].name < versions[j].name
+		}
+
+		return versions[i].modified.Before(versions[j].modified)
+	})
+
+	files := make([]string, 0, len(versions)+1)
+	for _, v := range versions {
+		if v.name != fileName {
+			files = append(files, v.name)
+		}
+	}
+
+	if _, err := os.Stat(fileName); err == nil {
+		files = append(files, fileName)
+	} else if len(files) == 0 {
+		return nil, errors.NewError(err)
+	}
+
+	return files, nil
+}
+
+// readLogFile reads the records from a log file, which may be compressed.
+func readLogFile(fileName string, fn func(LogRecord)) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return errors.NewError(err)
+	}
+
+	defer file.Close()
+
+	var reader io.Reader = file
+
+	if strings.HasSuffix(fileName, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return errors.NewError(err).Context(fileName)
+		}
+
+		defer gz.Close()
+
+		reader = gz
+	}
+
+	lines := []string{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return errors.NewError(err).Context(fileName)
+	}
+
+	parseLogLines(lines, fn)
+
+	return nil
+}
+
+// parseLogLines converts log lines to records. A text line that does not
+// start a message, such as a line of a formatted payload, is added to the
+// message before it.
+func parseLogLines(lines []string, fn func(LogRecord)) {
+	var current *LogRecord
+
+	flush := func() {
+		if current != nil {
+			fn(*current)
+			current = nil
+		}
+	}
+
+	for _, line := range lines {
+		if record, ok := parseLogLine(line); ok {
+			flush()
+
+			current = &record
+
+			continue
+		}
+
+		if current != nil {
+			current.Message = current.Message + "\n" + line
+		}
+	}
+
+	flush()
+}
+
+// parseLogLine converts a line in the text or JSON log format to a record. The
+// result is false if the line does not start a message.
+func parseLogLine(line string) (LogRecord, bool) {
+	if strings.HasPrefix(line, "{") {
+		entry := logEntry{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return LogRecord{}, false
+		}
+
+		stamp, _ := time.Parse(time.RFC3339Nano, entry.Time)
+
+		return LogRecord{
+			Time:     stamp,
+			Sequence: entry.Sequence,
+			Class:    entry.Class,
+			Level:    entry.Level,
+			Message:  entry.Message,
+			Session:  entry.Session,
+			Fields:   entry.Fields,
+		}, true
+	}
+
+	// A text line is "[time] sequence CLASS : message".
+	if !strings.HasPrefix(line, "[") {
+		return LogRecord{}, false
+	}
+
+	stampText, rest, found := strings.Cut(line[1:], "] ")
+	if !found {
+		return LogRecord{}, false
+	}
+
+	format := LogTimeStampFormat
+	if format == "" {
+		format = "2006-01-02 15:04:05"
+	}
+
+	stamp, err := time.ParseInLocation(format, stampText, time.Local)
+	if err != nil {
+		return LogRecord{}, false
+	}
+
+	header, message, found := strings.Cut(rest, ": ")
+	if !found {
+		return LogRecord{}, false
+	}
+
+	parts := strings.Fields(header)
+	if len(parts) != 2 {
+		return LogRecord{}, false
+	}
+
+	sequence, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return LogRecord{}, false
+	}
+
+	return LogRecord{
+		Time:     stamp,
+		Sequence: sequence,
+		Class:    parts[1],
+		Message:  message,
+		Session:  messageSession(message),
+	}, true
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueryLog(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "server.log")
+
+	// The oldest version is compressed, the next is a rolled over text file,
+	// and the current file uses the JSON format.
+	oldest := filepath.Join(dir, "server_2024-05-01-000000.log.gz")
+
+	file, err := os.Create(oldest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gz := gzip.NewWriter(file)
+	_, _ = gz.Write([]byte(
+		"[2024-05-01 10:00:00] 1     SERVER : [1] starting\n" +
+			"[2024-05-01 10:00:01] 2     APP    : loading settings\n"))
+	_ = gz.Close()
+	_ = file.Close()
+
+	older := filepath.Join(dir, "server_2024-05-02-000000.log")
+	_ = os.WriteFile(older, []byte(
+		"[2024-05-02 09:00:00] 3     REST   : [2] request payload\n"+
+			"{\n  \"user\": \"joe\"\n}\n"+
+			"[2024-05-02 09:30:00] 4     SERVER : [2] request complete\n"), 0644)
+
+	_ = os.WriteFile(base, []byte(
+		`{"time":"2024-05-03T08:00:00Z","seq":5,"class":"SERVER","level":"info","msg":"[3] shutting down","session":3}`+"\n"+
+			`{"time":"2024-05-03T08:00:01Z","seq":6,"class":"APP","level":"warn","msg":"settings not saved"}`+"\n"), 0644)
+
+	// Make the modification times follow the order of the versions.
+	now := time.Now()
+	_ = os.Chtimes(oldest, now.Add(-2*time.Hour), now.Add(-2*time.Hour))
+	_ = os.Chtimes(older, now.Add(-time.Hour), now.Add(-time.Hour))
+
+	tests := []struct {
+		name  string
+		query LogQuery
+		want  []int
+	}{
+		{
+			name:  "all messages",
+			query: LogQuery{},
+			want:  []int{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:  "class",
+			query: LogQuery{Classes: []string{"server"}},
+			want:  []int{1, 4, 5},
+		},
+		{
+			name:  "time range",
+			query: LogQuery{Since: time.Date(2024, 5, 2, 0, 0, 0, 0, time.Local), Until: time.Date(2024, 5, 2, 23, 0, 0, 0, time.Local)},
+			want:  []int{3, 4},
+		},
+		{
+			name:  "sequence range",
+			query: LogQuery{FirstSequence: 2, LastSequence: 4},
+			want:  []int{2, 3, 4},
+		},
+		{
+			name:  "session",
+			query: LogQuery{Session: 2},
+			want:  []int{3, 4},
+		},
+		{
+			name:  "pattern matches continuation lines",
+			query: LogQuery{Pattern: `"user": "joe"`},
+			want:  []int{3},
+		},
+		{
+			name:  "limit keeps the most recent",
+			query: LogQuery{Classes: []string{"APP", "SERVER"}, Limit: 2},
+			want:  []int{5, 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.File = base
+
+			records, err := QueryLog(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := []int{}
+			for _, record := range records {
+				got = append(got, record.Sequence)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("QueryLog() returned sequences %v, want %v", got, tt.want)
+			}
+
+			for n := range got {
+				if got[n] != tt.want[n] {
+					t.Fatalf("QueryLog() returned sequences %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := QueryLog(LogQuery{File: base, Pattern: "(unclosed"}); err == nil {
+		t.Errorf("invalid pattern was accepted")
+	}
+}
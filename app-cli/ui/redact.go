@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tucats/gopackages/errors"
+)
+
+// RedactedText replaces sensitive values in log messages.
+const RedactedText = "********"
+
+// RedactLogs indicates if sensitive values are masked in log messages. It is
+// true by default.
+var RedactLogs = true
+
+// The key names whose values are masked. A key matches if it is one of these
+// names, or ends with one of them after an underscore or hyphen, so "token"
+// also matches "refresh_token" and "X-Auth-Token".
+var redactedKeys = map[string]bool{
+	"apikey":        true,
+	"api_key":       true,
+	"authorization": true,
+	"cookie":        true,
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+}
+
+// The patterns registered by the application. If a pattern has groups, only
+// the text matched by the groups is masked; otherwise the whole match is.
+var redactionPatterns []*regexp.Regexp
+
+var redactMutex sync.RWMutex
+
+var (
+	// A JSON string field, such as "password": "value".
+	jsonFieldPattern = regexp.MustCompile(`"([^"\\]+)"(\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+
+	// A header line, such as "Authorization: Bearer value".
+	headerPattern = regexp.MustCompile(`(?m)^([ \t]*[A-Za-z0-9_-]+)([ \t]*:[ \t]*)(\S.*)$`)
+
+	// A form or query parameter, such as "password=value".
+	parameterPattern = regexp.MustCompile(`([A-Za-z0-9_.-]+)=([^&\s"]+)`)
+
+	// The credentials of an Authorization header value, wherever they appear.
+	credentialsPattern = regexp.MustCompile(`(?i)\b(Bearer|Basic)(\s+)[A-Za-z0-9._~+/-]{8,}=*`)
+)
+
+// AddRedactedKeys adds key names whose values are masked in log messages.
+// Names are not case-sensitive.
+func AddRedactedKeys(names ...string) {
+	redactMutex.Lock()
+	defer redactMutex.Unlock()
+
+	for _, name := range names {
+		if name = normalizeKey(name); name != "" {
+			redactedKeys[name] = true
+		}
+	}
+}
+
+// RedactedKeys returns the key names whose values are masked, in sorted order.
+func RedactedKeys() []string {
+	redactMutex.RLock()
+	defer redactMutex.RUnlock()
+
+	names := make([]string, 0, len(redactedKeys))
+	for name := range redactedKeys {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// AddRedactionPattern registers a regular expression whose matches are masked
+// in log messages. If the expression has groups, only the text matched by the
+// groups is masked, so `account=(\d+)` keeps "account=" but masks the number.
+func AddRedactionPattern(pattern string) error {
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return errors.ErrInvalidValue.Context(pattern)
+	}
+
+	redactMutex.Lock()
+	defer redactMutex.Unlock()
+
+	redactionPatterns = append(redactionPatterns, expression)
+
+	return nil
+}
+
+// Redact masks the sensitive values in a message. This includes the values of
+// redacted keys in JSON fields, header lines, and form parameters, the
+// credentials of an Authorization header, and the text matched by the
+// registered patterns.
+func Redact(text string) string {
+	redactMutex.RLock()
+	defer redactMutex.RUnlock()
+
+	text = replaceGroups(jsonFieldPattern, text, func(groups []string) string {
+		if isRedactedKey(groups[1]) {
+			return `"` + groups[1] + `"` + groups[2] + `"` + RedactedText + `"`
+		}
+
+		return groups[0]
+	})
+
+	text = replaceGroups(headerPattern, text, func(groups []string) string {
+		if isRedactedKey(groups[1]) {
+			return groups[1] + groups[2] + RedactedText
+		}
+
+		return groups[0]
+	})
+
+	text = replaceGroups(parameterPattern, text, func(groups []string) string {
+		if isRedactedKey(groups[1]) {
+			return groups[1] + "=" + RedactedText
+		}
+
+		return groups[0]
+	})
+
+	text = credentialsPattern.ReplaceAllString(text, "${1}${2}"+RedactedText)
+
+	for _, pattern := range redactionPatterns {
+		text = redactMatches(pattern, text)
+	}
+
+	return text
+}
+
+// redactFields returns a copy of the fields with the values of redacted keys
+// masked, and the other string values redacted.
+func redactFields(fields Fields) Fields {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	result := Fields{}
+
+	for name, value := range fields {
+		redactMutex.RLock()
+		redacted := isRedactedKey(name)
+		redactMutex.RUnlock()
+
+		switch actual := value.(type) {
+		case string:
+			if redacted {
+				result[name] = RedactedText
+			} else {
+				result[name] = Redact(actual)
+			}
+
+		default:
+			if redacted {
+				result[name] = RedactedText
+			} else {
+				result[name] = value
+			}
+		}
+	}
+
+	return result
+}
+
+// isRedactedKey reports whether the values of a key are masked. The caller
+// must hold the redaction mutex.
+func isRedactedKey(name string) bool {
+	name = normalizeKey(name)
+
+	for key := range redactedKeys {
+		if name == key || strings.HasSuffix(name, "_"+key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeKey converts a key name to lower case, with hyphens replaced by
+// underscores.
+func normalizeKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+}
+
+// replaceGroups replaces each match of the expression with the result of the
+// function, which is given the match followed by its groups.
+func replaceGroups(expression *regexp.Regexp, text string, fn func(groups []string) string) string {
+	return expression.ReplaceAllStringFunc(text, func(match string) string {
+		return fn(expression.FindStringSubmatch(match))
+	})
+}
+
+// redactMatches masks the text matched by a registered pattern, or by its
+// groups if it has any.
+func redactMatches(expression *regexp.Regexp, text string) string {
+	if expression.NumSubexp() == 0 {
+		return expression.ReplaceAllString(text, RedactedText)
+	}
+
+	b := strings.Builder{}
+	last := 0
+
+	for _, match := range expression.FindAllStringSubmatchIndex(text, -1) {
+		for group := 1; group <= expression.NumSubexp(); group++ {
+			start, end := match[2*group], match[2*group+1]
+			if start < last || start < 0 {
+				continue
+			}
+
+			b.WriteString(text[last:start])
+			b.WriteString(RedactedText)
+			last = end
+		}
+	}
+
+	b.WriteString(text[last:])
+
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	if err := AddRedactionPattern(`account=(\d+)`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := AddRedactionPattern(`\d{3}-\d{2}-\d{4}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	AddRedactedKeys("Session-ID")
+
+	defer func() {
+		redactionPatterns = nil
+
+		delete(redactedKeys, "session_id")
+	}()
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "JSON payload",
+			text: "Request payload:\n{\n  \"username\": \"joe\",\n  \"password\": \"s3cr\\\"et\",\n  \"refresh_token\": \"abc\"\n}",
+			want: "Request payload:\n{\n  \"username\": \"joe\",\n  \"password\": \"********\",\n  \"refresh_token\": \"********\"\n}",
+		},
+		{
+			name: "header dump",
+			text: "Headers:\nAccept: application/json\nAuthorization: Bearer abcdefghijkl\nX-Auth-Token: 1234",
+			want: "Headers:\nAccept: application/json\nAuthorization: ********\nX-Auth-Token: ********",
+		},
+		{
+			name: "form parameters",
+			text: "POST /token?grant_type=refresh&client_secret=xyz&user=joe",
+			want: "POST /token?grant_type=refresh&client_secret=********&user=joe",
+		},
+		{
+			name: "bearer credentials in text",
+			text: "retrying with Bearer eyJhbGciOiJIUzI1NiJ9.payload.sig",
+			want: "retrying with Bearer ********",
+		},
+		{
+			name: "ordinary text is unchanged",
+			text: "Authorization set using bearer token; Token for server expires in 5m",
+			want: "Authorization set using bearer token; Token for server expires in 5m",
+		},
+		{
+			name: "added key",
+			text: `{"session-id": "42"}`,
+			want: `{"session-id": "********"}`,
+		},
+		{
+			name: "pattern with group",
+			text: "transfer from account=12345 to account=67890",
+			want: "transfer from account=******** to account=********",
+		},
+		{
+			name: "pattern without group",
+			text: "ssn 123-45-6789 on file",
+			want: "ssn ******** on file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.text); got != tt.want {
+				t.Errorf("Redact() got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if err := AddRedactionPattern("(unclosed"); err == nil {
+		t.Errorf("invalid pattern was accepted")
+	}
+}
+
+func TestRedactLogEntry(t *testing.T) {
+	line := formatLogEntry(LoggerByName("REST"), InfoLevel, Fields{"token": "abc", "user": "joe"}, `body {"password": "pw"}`)
+
+	if strings.Contains(line, "abc") || strings.Contains(line, `"pw"`) || !strings.Contains(line, "user=joe") {
+		t.Errorf("log entry was not redacted: %s", line)
+	}
+
+	RedactLogs = false
+	defer func() { RedactLogs = true }()
+
+	line = formatLogEntry(LoggerByName("REST"), InfoLevel, nil, `body {"password": "pw"}`)
+	if !strings.Contains(line, `"pw"`) {
+		t.Errorf("log entry was redacted when redaction is disabled: %s", line)
+	}
+}
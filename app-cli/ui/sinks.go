@@ -0,0 +1,332 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tucats/gopackages/errors"
+)
+
+// DefaultSyslogSocket is the Unix datagram socket used by a syslog sink if
+// no other socket is given.
+const DefaultSyslogSocket = "/dev/log"
+
+// DefaultRingSize is the number of messages kept by a ring buffer sink if no
+// other size is given.
+const DefaultRingSize = 1000
+
+// LogSink receives formatted log messages. A sink is added with AddLogSink().
+// The line does not end with a newline.
+type LogSink interface {
+	Write(class, level int, line string) error
+	Close() error
+}
+
+// sinkEntry is a sink and the logger classes it receives. If there are no
+// classes, the sink receives messages for all loggers.
+type sinkEntry struct {
+	name    string
+	sink    LogSink
+	classes map[int]bool
+}
+
+var sinks []sinkEntry
+var sinksMutex sync.RWMutex
+
+// AddLogSink adds a sink that receives log messages, in addition to the log
+// file. If classes are given, the sink only receives messages from those
+// loggers. A sink that already has the same name is closed and replaced.
+// While there is at least one sink, log messages are no longer written to
+// stderr when there is no log file.
+func AddLogSink(name string, sink LogSink, classes ...int) {
+	entry := sinkEntry{name: name, sink: sink}
+
+	if len(classes) > 0 {
+		entry.classes = map[int]bool{}
+		for _, class := range classes {
+			entry.classes[class] = true
+		}
+	}
+
+	RemoveLogSink(name)
+
+	sinksMutex.Lock()
+	sinks = append(sinks, entry)
+	sinksMutex.Unlock()
+}
+
+// RemoveLogSink closes and removes the named sink. The result is false if
+// there was no such sink.
+func RemoveLogSink(name string) bool {
+	sinksMutex.Lock()
+	defer sinksMutex.Unlock()
+
+	for n, entry := range sinks {
+		if entry.name == name {
+			_ = entry.sink.Close()
+			sinks = append(sinks[:n], sinks[n+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// LogSinks returns the names of the sinks, in sorted order.
+func LogSinks() []string {
+	sinksMutex.RLock()
+	defer sinksMutex.RUnlock()
+
+	names := make([]string, len(sinks))
+	for n, entry := range sinks {
+		names[n] = entry.name
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// writeLogSinks sends a message to each sink that accepts the logger class.
+// The result is false if there are no sinks. A sink that fails is removed,
+// and the error is reported to the remaining sinks.
+func writeLogSinks(class, level int, line string) bool {
+	sinksMutex.RLock()
+
+	if len(sinks) == 0 {
+		sinksMutex.RUnlock()
+
+		return false
+	}
+
+	failed := map[string]error{}
+
+	for _, entry := range sinks {
+		if entry.classes != nil && !entry.classes[class] {
+			continue
+		}
+
+		if err := entry.sink.Write(class, level, line); err != nil {
+			failed[entry.name] = err
+		}
+	}
+
+	sinksMutex.RUnlock()
+
+	for name, err := range failed {
+		RemoveLogSink(name)
+		WriteLog(InternalLogger, "ERROR: log sink %s removed; %v", name, err)
+	}
+
+	return true
+}
+
+// streamSink writes messages to a stream, such as stderr.
+type streamSink struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+// NewStreamSink returns a sink that writes each message as a line to the
+// writer. It is typically used with os.Stderr, so log messages are kept
+// separate from the output of a command. Closing the sink does not close
+// the writer.
+func NewStreamSink(w io.Writer) LogSink {
+	return &streamSink{writer: w}
+}
+
+func (s *streamSink) Write(class, level int, line string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := io.WriteString(s.writer, line+"\n")
+
+	return err
+}
+
+func (s *streamSink) Close() error {
+	return nil
+}
+
+// fileSink appends messages to a file.
+type fileSink struct {
+	file  *os.File
+	mutex sync.Mutex
+}
+
+// NewFileSink returns a sink that appends messages to the named file. Unlike
+// the file opened by OpenLogFile(), the file is never rolled over.
+func NewFileSink(path string) (LogSink, error) {
+	fileName, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	return &fileSink{file: file}, nil
+}
+
+func (s *fileSink) Write(class, level int, line string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.file.WriteString(line + "\n")
+
+	return err
+}
+
+func (s *fileSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.file.Close()
+}
+
+// syslogSink sends messages to a Unix datagram socket in syslog format.
+type syslogSink struct {
+	conn  net.Conn
+	tag   string
+	mutex sync.Mutex
+}
+
+// NewSyslogSink returns a sink that sends each message to the Unix datagram
+// socket in the RFC 3164 syslog format, using the "user" facility and the
+// tag. If the socket is empty, DefaultSyslogSocket is used.
+func NewSyslogSink(socket, tag string) (LogSink, error) {
+	if socket == "" {
+		socket = DefaultSyslogSocket
+	}
+
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	return &syslogSink{conn: conn, tag: tag}, nil
+}
+
+func (s *syslogSink) Write(class, level int, line string) error {
+	// The priority is the "user" facility (1) times 8, plus the severity.
+	priority := 8 + syslogSeverity(level)
+	message := fmt.Sprintf("<%d>%s %s[%d]: %s", priority, time.Now().Format(time.Stamp), s.tag, os.Getpid(), line)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.conn.Write([]byte(message))
+
+	return err
+}
+
+func (s *syslogSink) Close() error {
+	return s.conn.Close()
+}
+
+// syslogSeverity returns the syslog severity for a log level.
+func syslogSeverity(level int) int {
+	switch level {
+	case ErrorLevel:
+		return 3
+
+	case WarnLevel:
+		return 4
+
+	case InfoLevel:
+		return 6
+
+	default:
+		return 7
+	}
+}
+
+// RingSink keeps the most recent log messages in memory. Tail() uses the
+// first ring buffer sink, if there is one, instead of reading the log file.
+type RingSink struct {
+	lines []string
+	next  int
+	full  bool
+	mutex sync.Mutex
+}
+
+// NewRingSink returns a sink that keeps the given number of the most recent
+// messages. If the size is not positive, DefaultRingSize is used.
+func NewRingSink(size int) *RingSink {
+	if size <= 0 {
+		size = DefaultRingSize
+	}
+
+	return &RingSink{lines: make([]string, size)}
+}
+
+func (r *RingSink) Write(class, level int, line string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+
+	if r.next == 0 {
+		r.full = true
+	}
+
+	return nil
+}
+
+func (r *RingSink) Close() error {
+	return nil
+}
+
+// Lines returns up to count of the most recent messages, oldest first. If the
+// session is greater than zero, only messages for that session are returned.
+func (r *RingSink) Lines(count int, session int) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var ordered []string
+	if r.full {
+		ordered = append(append(ordered, r.lines[r.next:]...), r.lines[:r.next]...)
+	} else {
+		ordered = append(ordered, r.lines[:r.next]...)
+	}
+
+	text := []string{}
+
+	for _, line := range ordered {
+		if session > 0 && !lineHasSession(line, session) {
+			continue
+		}
+
+		text = append(text, line)
+	}
+
+	if position := len(text) - count; position > 0 {
+		text = text[position:]
+	}
+
+	return text
+}
+
+// ringSink returns the first ring buffer sink, or nil if there isn't one.
+func ringSink() *RingSink {
+	sinksMutex.RLock()
+	defer sinksMutex.RUnlock()
+
+	for _, entry := range sinks {
+		if ring, ok := entry.sink.(*RingSink); ok {
+			return ring
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"bytes"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tucats/gopackages/errors"
+)
+
+// failingSink always fails to write.
+type failingSink struct{}
+
+func (failingSink) Write(class, level int, line string) error { return errors.ErrInvalidLogSink }
+func (failingSink) Close() error                              { return nil }
+
+func TestLogSinks(t *testing.T) {
+	// Restore the message sequence number, which other tests depend on.
+	savedSequence := sequence
+	defer func() { sequence = savedSequence }()
+
+	var all, server bytes.Buffer
+
+	ring := NewRingSink(3)
+
+	AddLogSink("all", NewStreamSink(&all))
+	AddLogSink("server", NewStreamSink(&server), ServerLogger)
+	AddLogSink("ring", ring)
+	AddLogSink("failing", failingSink{})
+
+	defer func() {
+		for _, name := range LogSinks() {
+			RemoveLogSink(name)
+		}
+	}()
+
+	WriteLog(UserLogger, "user message")
+
+	if names := LogSinks(); strings.Join(names, ",") != "all,ring,server" {
+		t.Errorf("failing sink was not removed, sinks are %v", names)
+	}
+
+	WriteLog(ServerLogger, "[5] request one")
+	WriteLog(ServerLogger, "[6] request two")
+	WriteLog(ServerLogger, "[5] request three")
+
+	if got := strings.Count(all.String(), "\n"); got != 5 {
+		t.Errorf("all sink received %d lines, want 5:\n%s", got, all.String())
+	}
+
+	if got := strings.Count(server.String(), "\n"); got != 3 || strings.Contains(server.String(), "user message") {
+		t.Errorf("server sink received unexpected lines:\n%s", server.String())
+	}
+
+	lines := Tail(10, 0)
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "[5] request one") {
+		t.Errorf("Tail() returned %v", lines)
+	}
+
+	lines = Tail(10, 5)
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], "[5] request three") {
+		t.Errorf("Tail() for session 5 returned %v", lines)
+	}
+
+	if lines := ring.Lines(1, 0); len(lines) != 1 || !strings.HasSuffix(lines[0], "request three") {
+		t.Errorf("Lines() returned %v", lines)
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "log.sock")
+
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets are not available: %v", err)
+	}
+
+	defer listener.Close()
+
+	sink, err := NewSyslogSink(socket, "tester")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer sink.Close()
+
+	if err := sink.Write(ServerLogger, WarnLevel, "disk is filling up"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b := make([]byte, 1024)
+
+	n, err := listener.Read(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	message := string(b[:n])
+	if !strings.HasPrefix(message, "<12>") || !strings.Contains(message, " tester[") || !strings.HasSuffix(message, "]: disk is filling up") {
+		t.Errorf("unexpected syslog message %q", message)
+	}
+}
@@ -2,12 +2,21 @@ package ui
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 )
 
+// Tail returns up to count of the most recent log messages. If the session is
+// greater than zero, only messages for that session are returned. If there is
+// a ring buffer sink, the messages are taken from it; otherwise they are read
+// from the log file.
 func Tail(count int, session int) []string {
+	if ring := ringSink(); ring != nil {
+		return ring.Lines(count, session)
+	}
+
 	if logFile == nil {
 		return nil
 	}
@@ -27,12 +36,8 @@ func Tail(count int, session int) []string {
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		if session > 0 {
-			pattern := fmt.Sprintf(": [%d] ", session)
-
-			if !strings.Contains(line, pattern) {
-				continue
-			}
+		if session > 0 && !lineHasSession(line, session) {
+			continue
 		}
 
 		text = append(text, scanner.Text())
@@ -45,3 +50,17 @@ func Tail(count int, session int) []string {
 
 	return text[position:]
 }
+
+// lineHasSession reports whether a log line is for the given session. A line
+// in JSON format has a session field; a text line has a "[n] " prefix on the
+// message.
+func lineHasSession(line string, session int) bool {
+	if strings.HasPrefix(line, "{") {
+		entry := logEntry{}
+		if err := json.Unmarshal([]byte(line), &entry); err == nil {
+			return entry.Session == session
+		}
+	}
+
+	return strings.Contains(line, fmt.Sprintf(": [%d] ", session))
+}
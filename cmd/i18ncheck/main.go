@@ -0,0 +1,182 @@
+// Command i18ncheck reports the message keys that are missing from, or not
+// used by, each language of the localization database. The database is the
+// messages built into the i18n package plus any message catalog files in the
+// directory given with -catalogs.
+//
+// A key is missing from a language if it has English text but no text in that
+// language. A key is unused if no string literal in the Go source files under
+// the -source directory refers to it. Keys built at runtime, such as by adding
+// a suffix to a prefix, are reported as unused and must be checked by hand.
+//
+// Usage:
+//
+//	i18ncheck [-catalogs dir] [-source dir] [-languages list] [-json]
+//
+// The exit status is 1 if any key is reported.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tucats/gopackages/app-cli/tables"
+	"github.com/tucats/gopackages/i18n"
+)
+
+// problem is a key reported for a language.
+type problem struct {
+	Language string `json:"language"`
+	Key      string `json:"key"`
+	Problem  string `json:"problem"`
+}
+
+func main() {
+	catalogs := flag.String("catalogs", "", "directory of message catalog files to load")
+	source := flag.String("source", ".", "directory of Go source files that use the messages")
+	languages := flag.String("languages", "", "comma-separated list of languages to check; the default is all")
+	asJSON := flag.Bool("json", false, "report in JSON format")
+
+	flag.Parse()
+
+	if *catalogs != "" {
+		if err := i18n.LoadCatalogs(os.DirFS(*catalogs), "."); err != nil {
+			fmt.Fprintf(os.Stderr, "i18ncheck: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	literals, err := sourceLiterals(*source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "i18ncheck: %v\n", err)
+		os.Exit(2)
+	}
+
+	list := i18n.Languages()
+	if *languages != "" {
+		list = strings.Split(*languages, ",")
+	}
+
+	problems := []problem{}
+
+	for _, language := range list {
+		language = strings.TrimSpace(language)
+
+		if language != "en" {
+			for _, key := range i18n.MissingKeys(language) {
+				problems = append(problems, problem{Language: language, Key: key, Problem: "missing"})
+			}
+		}
+
+		for _, key := range i18n.UnusedKeys(language, literals) {
+			problems = append(problems, problem{Language: language, Key: key, Problem: "unused"})
+		}
+	}
+
+	if *asJSON {
+		b, _ := json.MarshalIndent(problems, "", "  ")
+		fmt.Println(string(b))
+	} else if len(problems) > 0 {
+		t, _ := tables.New([]string{"Language", "Key", "Problem"})
+
+		for _, p := range problems {
+			_ = t.AddRowItems(p.Language, p.Key, p.Problem)
+		}
+
+		t.SetPagination(0, 0)
+		_ = t.Print("text")
+	}
+
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// sourceLiterals returns the values of the string literals in the Go source
+// files in a directory tree. Directories whose names start with "." or "_",
+// and the testdata and vendor directories, are skipped.
+func sourceLiterals(root string) (map[string]bool, error) {
+	literals := map[string]bool{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := entry.Name()
+
+		if entry.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(name, ".go") {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		fileSet := token.NewFileSet()
+		file := fileSet.AddFile(path, -1, len(src))
+
+		var s scanner.Scanner
+
+		s.Init(file, src, nil, 0)
+
+		// A string followed by ":" and "{" is the key of an entry in a map of
+		// localizations, such as the one in the i18n package, and does not
+		// count as a use of the key.
+		pending := []string{}
+		keep := func() {
+			if len(pending) > 0 {
+				if value, err := strconv.Unquote(pending[0]); err == nil {
+					literals[value] = true
+				}
+			}
+
+			pending = pending[:0]
+		}
+
+		for {
+			_, tok, text := s.Scan()
+			if tok == token.EOF {
+				keep()
+
+				break
+			}
+
+			switch {
+			case tok == token.STRING:
+				keep()
+
+				pending = append(pending, text)
+
+			case tok == token.COLON && len(pending) == 1:
+				pending = append(pending, ":")
+
+			case tok == token.LBRACE && len(pending) == 2:
+				pending = pending[:0]
+
+			default:
+				keep()
+			}
+		}
+
+		return nil
+	})
+
+	return literals, err
+}
@@ -9,9 +9,21 @@ const LibPathName = "lib"
 const DefaultLogging = "APP_DEFAULT_LOGGING"
 
 // The environment variable that contains the name to use for writing log
-// file messages. If not specified, defaults to writing to stdout.
+// file messages. If not specified, defaults to writing to stderr.
 const DefaultLogFileName = "APP_LOG_FILE"
 
+// The environment variable that contains the format of log messages, either
+// "text" or "json". If not specified, defaults to text.
+const DefaultLogFormat = "APP_LOG_FORMAT"
+
+// The environment variable that contains the list of additional destinations
+// for log messages, such as "stderr" or "syslog".
+const DefaultLogSinks = "APP_LOG_SINKS"
+
+// The environment variable that contains the password used to log on, and
+// to send with each request when using basic authentication.
+const DefaultPassword = "APP_PASSWORD"
+
 // This is the name of a column automatically added to tables created using
 // the 'tables' REST API.
 const RowIDName = "_row_id_"
@@ -59,6 +71,58 @@ const (
 	// to the client user than "not authorized".
 	LogonTokenExpirationSetting = PrivilegedKeyPrefix + "logon.token.expiration"
 
+	// Stores the credentials for each server the user has logged on to,
+	// as a JSON map keyed by the server URL. This allows the user to be
+	// logged on to more than one server at a time.
+	LogonCredentialsSetting = PrivilegedKeyPrefix + "logon.credentials"
+
+	// How long before a token expires that it is automatically refreshed,
+	// expressed as a duration string such as "5m".
+	LogonRefreshWindowSetting = PrivilegedKeyPrefix + "logon.refresh.window"
+
+	// The name of the authentication method used by the logon command,
+	// such as "token", "basic", "apikey", "certificate", or "oauth2". If
+	// not set, the Ego token logon is used.
+	LogonAuthenticationSetting = PrivilegedKeyPrefix + "logon.authentication"
+
+	// The name of the request header used to send an API key when the
+	// "apikey" authentication method is used.
+	LogonAPIKeyHeaderSetting = PrivilegedKeyPrefix + "logon.apikey.header"
+
+	// The OAuth2 device authorization endpoint, token endpoint, client ID,
+	// and optional scope used by the "oauth2" authentication method.
+	OAuthDeviceURLSetting = PrivilegedKeyPrefix + "logon.oauth.device.url"
+	OAuthTokenURLSetting  = PrivilegedKeyPrefix + "logon.oauth.token.url"
+	OAuthClientIDSetting  = PrivilegedKeyPrefix + "logon.oauth.client.id"
+	OAuthScopeSetting     = PrivilegedKeyPrefix + "logon.oauth.scope"
+
+	// The files containing the client certificate and its private key,
+	// used for mutual TLS authentication. If set, the certificate is
+	// presented to every server.
+	ClientCertificateFileSetting = PrivilegedKeyPrefix + "tls.client.cert"
+	ClientKeyFileSetting         = PrivilegedKeyPrefix + "tls.client.key"
+
+	// A list of files containing PEM encoded certificate authorities that
+	// are trusted in addition to the system roots, separated by commas.
+	TLSCABundleSetting = PrivilegedKeyPrefix + "tls.ca.bundle"
+
+	// The minimum TLS version used to connect to a server, such as "1.2".
+	TLSMinVersionSetting = PrivilegedKeyPrefix + "tls.min.version"
+
+	// The server name sent in the TLS handshake (SNI) and used to verify
+	// the server certificate, if it differs from the host in the URL.
+	TLSServerNameSetting = PrivilegedKeyPrefix + "tls.server.name"
+
+	// The URL of the proxy used for REST requests, using the http, https,
+	// or socks5 scheme. If not set, the HTTPS_PROXY, HTTP_PROXY, and
+	// NO_PROXY environment variables are used.
+	RestProxySetting = PrivilegedKeyPrefix + "rest.proxy"
+
+	// A list of hosts that are not reached through the proxy, separated by
+	// commas. An entry can be a host name, a domain starting with ".", an
+	// IP address, a CIDR network, or "*" for all hosts.
+	RestNoProxySetting = PrivilegedKeyPrefix + "rest.no.proxy"
+
 	// Default allocation factor to set on symbol table create/expand
 	// operations. Larger numbers are more efficient for larger symbol
 	// tables, but too large a number wastes time and memory.
@@ -72,6 +136,79 @@ const (
 	// If true, the TRACE operation will print the full stack instead of
 	// a shorter single-line version.
 	FullStackTraceSetting = PrivilegedKeyPrefix + "runtime.stack.trace"
+
+	// The number of prior versions of each configuration that are kept
+	// in the profile history. If zero, no history is kept.
+	ConfigHistoryDepthSetting = PrivilegedKeyPrefix + "config.history.depth"
+
+	// The maximum time allowed for each attempt at a REST request, expressed
+	// as a duration string such as "30s". If zero, there is no timeout.
+	RestTimeoutSetting = PrivilegedKeyPrefix + "rest.timeout"
+
+	// The maximum number of times a REST request is retried after a network
+	// error or a transient status such as 503. Only idempotent methods are
+	// retried.
+	RestRetryCountSetting = PrivilegedKeyPrefix + "rest.retries"
+
+	// The base delay before the first retry of a REST request. The delay
+	// doubles for each subsequent retry.
+	RestRetryDelaySetting = PrivilegedKeyPrefix + "rest.retry.delay"
+
+	// The maximum delay between retries of a REST request.
+	RestRetryMaxDelaySetting = PrivilegedKeyPrefix + "rest.retry.max.delay"
+
+	// The name of a cassette file used to record REST traffic, or to replay
+	// it without a server. This can also be set using the environment
+	// variable APP_REST_CASSETTE.
+	RestCassetteSetting = PrivilegedKeyPrefix + "rest.cassette"
+
+	// Whether the cassette is used to "record" or "replay" REST traffic. The
+	// default is "replay". This can also be set using the environment
+	// variable APP_REST_CASSETTE_MODE.
+	RestCassetteModeSetting = PrivilegedKeyPrefix + "rest.cassette.mode"
+
+	// How replayed requests are matched to the recording; "order" replays
+	// the responses in the order they were recorded, and "request" finds
+	// the response with the same method, URL, and body. The default is
+	// "order". This can also be set using the environment variable
+	// APP_REST_CASSETTE_MATCH.
+	RestCassetteMatchSetting = PrivilegedKeyPrefix + "rest.cassette.match"
+
+	// If true, the responses to REST GET requests are cached in the profile
+	// directory, and conditional requests are used to revalidate them.
+	RestCacheSetting = PrivilegedKeyPrefix + "rest.cache"
+
+	// The size at which the log file is rolled over, as a number of bytes
+	// optionally followed by KB, MB, or GB. If not set, the log file is not
+	// rolled over based on its size.
+	LogMaxSizeSetting = PrivilegedKeyPrefix + "log.max.size"
+
+	// The number of rolled over log files to keep. The default is 3.
+	LogRetainSetting = PrivilegedKeyPrefix + "log.retain"
+
+	// The age, such as "72h" or "7d", after which rolled over log files are
+	// deleted. If not set, they are only deleted based on the retain count.
+	LogMaxAgeSetting = PrivilegedKeyPrefix + "log.max.age"
+
+	// If true, rolled over log files are compressed using gzip.
+	LogCompressSetting = PrivilegedKeyPrefix + "log.compress"
+
+	// If false, sensitive values such as passwords and tokens are not
+	// masked in log messages. The default is true.
+	LogRedactSetting = PrivilegedKeyPrefix + "log.redact"
+
+	// A comma-separated list of additional key names whose values are
+	// masked in log messages, such as "ssn,account_number".
+	LogRedactKeysSetting = PrivilegedKeyPrefix + "log.redact.keys"
+
+	// The directory of message catalog files that localize the application.
+	// If not set, the "i18n" directory in the profile directory is used.
+	CatalogPathSetting = PrivilegedKeyPrefix + "i18n.catalogs"
+
+	// If true, errors record the Go call stack where they are created, and
+	// an error that ends the application is reported with its causes and
+	// their stacks. This is the same as the --debug option.
+	ErrorStackSetting = PrivilegedKeyPrefix + "error.stack"
 )
 
 // Agent identifiers for REST calls, which indicate the role of the client.
@@ -133,6 +270,38 @@ var ValidSettings map[string]bool = map[string]bool{
 	LogonServerSetting:           true,
 	LogonTokenSetting:            false,
 	LogonTokenExpirationSetting:  false,
+	LogonCredentialsSetting:      false,
+	LogonRefreshWindowSetting:    true,
+	LogonAuthenticationSetting:   true,
+	LogonAPIKeyHeaderSetting:     true,
+	OAuthDeviceURLSetting:        true,
+	OAuthTokenURLSetting:         true,
+	OAuthClientIDSetting:         true,
+	OAuthScopeSetting:            true,
+	ClientCertificateFileSetting: true,
+	ClientKeyFileSetting:         true,
+	TLSCABundleSetting:           true,
+	TLSMinVersionSetting:         true,
+	TLSServerNameSetting:         true,
+	RestProxySetting:             true,
+	RestNoProxySetting:           true,
 	FullStackTraceSetting:        true,
 	SymbolTableAllocationSetting: true,
+	ConfigHistoryDepthSetting:    true,
+	RestTimeoutSetting:           true,
+	RestRetryCountSetting:        true,
+	RestRetryDelaySetting:        true,
+	RestRetryMaxDelaySetting:     true,
+	RestCassetteSetting:          true,
+	RestCassetteModeSetting:      true,
+	RestCassetteMatchSetting:     true,
+	RestCacheSetting:             true,
+	LogMaxSizeSetting:            true,
+	LogRetainSetting:             true,
+	LogMaxAgeSetting:             true,
+	LogCompressSetting:           true,
+	LogRedactSetting:             true,
+	LogRedactKeysSetting:         true,
+	CatalogPathSetting:           true,
+	ErrorStackSetting:            true,
 }
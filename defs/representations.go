@@ -1,6 +1,7 @@
 package defs
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,6 +28,11 @@ type RestStatusResponse struct {
 	ServerInfo `json:"server"`
 	Status     int    `json:"status"`
 	Message    string `json:"msg"`
+
+	// The error that caused the response, in the JSON form written by the
+	// errors package. A client can read it into an errors.Error to match
+	// the error by its code.
+	Error json.RawMessage `json:"error,omitempty"`
 }
 
 type Table struct {
@@ -23,6 +23,8 @@ const (
 	CodePath                  = "/code"
 	ServicesPath              = "/services/"
 	ServicesLogonPath         = ServicesPath + "admin/logon/"
+	ServicesLogonRefreshPath  = ServicesPath + "admin/logon/refresh/"
+	ServicesLogoutPath        = ServicesPath + "admin/logout/"
 	ServicesUpPath            = ServicesPath + "up/"
 	TablesPath                = "/tables/"
 	TablesNamePath            = TablesPath + "%s"
@@ -48,9 +50,11 @@ var TableColumnTypeNames []string = []string{
 }
 
 const (
-	TextMediaType = "application/text"
-	JSONMediaType = "application/json"
-	HTMLMediaType = "application/html"
+	TextMediaType   = "application/text"
+	JSONMediaType   = "application/json"
+	HTMLMediaType   = "application/html"
+	NDJSONMediaType = "application/x-ndjson"
+	BinaryMediaType = "application/octet-stream"
 
 	EgoMediaType            = "application/vnd.ego."
 	SQLStatementsMediaType  = EgoMediaType + "sql+json"
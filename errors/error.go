@@ -3,7 +3,9 @@ package errors
 import (
 	goerror "errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/tucats/gopackages/defs"
 	"github.com/tucats/gopackages/i18n"
@@ -27,8 +29,29 @@ type Error struct {
 	err      error
 	location *location
 	context  string
+
+	// The stable, machine-readable code of the error, which is the i18n key
+	// of its message, such as "log.level". Errors that do not come from
+	// NewMessage have no code.
+	code string
+
+	// Additional errors that caused this one.
+	causes []error
+
+	// Structured context values, set with With().
+	fields map[string]interface{}
+
+	// The Go call stack where the error was created, if stacks are being
+	// captured.
+	stack []uintptr
 }
 
+// The errors created by NewMessage, by code. This is used to find the
+// predefined error for a code read from JSON.
+var codes = map[string]*Error{}
+
+var codeMutex sync.Mutex
+
 // NewError creates a new NewError object, and fils in the native
 // wrapped error. Note that if the value passed in is already
 // an NewError, then it is returned without re-wrapping it.
@@ -41,9 +64,154 @@ func NewError(err error) *Error {
 		return e
 	}
 
+	// An error from the standard library's errors.Join() becomes an error
+	// with each of the joined errors as a cause.
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return Join(joined.Unwrap()...)
+	}
+
 	return &Error{
-		err: err,
+		err:   err,
+		stack: callers(),
+	}
+}
+
+// Join returns an error whose causes are the errors given, like the standard
+// library's errors.Join(). Nil errors are discarded. If there are none, the
+// result is nil, and if there is only one, the result is that error.
+func Join(errs ...error) *Error {
+	causes := make([]error, 0, len(errs))
+
+	for _, err := range errs {
+		if !Nil(err) {
+			causes = append(causes, err)
+		}
+	}
+
+	switch len(causes) {
+	case 0:
+		return nil
+
+	case 1:
+		return NewError(causes[0])
+	}
+
+	return ErrMultipleErrors.Wrap(causes...)
+}
+
+// Wrap returns a copy of the error with the given errors added to its causes.
+// Because it returns a copy, it can be used with the predefined errors, such
+// as errors.ErrNoSuchUser.Wrap(err).
+func (e *Error) Wrap(causes ...error) *Error {
+	if e == nil {
+		return nil
+	}
+
+	result := e.clone()
+	if result.stack == nil {
+		result.stack = callers()
+	}
+
+	for _, cause := range causes {
+		if !Nil(cause) {
+			result.causes = append(result.causes, cause)
+		}
+	}
+
+	return result
+}
+
+// Causes returns the errors that caused this error, if any.
+func (e *Error) Causes() []error {
+	if e == nil {
+		return nil
 	}
+
+	return append([]error{}, e.causes...)
+}
+
+// With returns a copy of the error with a structured context value, such as
+// the name of a file or a user. The values are included in the text of the
+// error and in its JSON form. Because it returns a copy, it can be used with
+// the predefined errors.
+func (e *Error) With(key string, value interface{}) *Error {
+	if e == nil {
+		return nil
+	}
+
+	result := e.clone()
+	result.fields[key] = value
+
+	if result.stack == nil {
+		result.stack = callers()
+	}
+
+	return result
+}
+
+// Fields returns the structured context values of the error.
+func (e *Error) Fields() map[string]interface{} {
+	result := map[string]interface{}{}
+
+	if e != nil {
+		for key, value := range e.fields {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// Code returns the stable, machine-readable code of the error, such as
+// "log.level". This does not change with the language of the message, so
+// it should be used instead of the message text to identify an error. If
+// the error has no code, an empty string is returned.
+func (e *Error) Code() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.code
+}
+
+// Code returns the code of the first error in the chain of wrapped errors
+// that has one. If there is none, an empty string is returned.
+func Code(err error) string {
+	for err != nil {
+		if e, ok := err.(interface{ Code() string }); ok {
+			if code := e.Code(); code != "" {
+				return code
+			}
+		}
+
+		err = goerror.Unwrap(err)
+	}
+
+	return ""
+}
+
+// ForCode returns the predefined error with the given code, or nil if there
+// is none.
+func ForCode(code string) *Error {
+	codeMutex.Lock()
+	defer codeMutex.Unlock()
+
+	return codes[code]
+}
+
+// clone returns a copy of the error that does not share its causes or
+// context values with the original.
+func (e *Error) clone() *Error {
+	result := *e
+	result.causes = append([]error{}, e.causes...)
+	result.fields = e.Fields()
+
+	if e.location != nil {
+		location := *e.location
+		result.location = &location
+	}
+
+	return &result
 }
 
 // In specifies the location name. This can be the name of
@@ -105,11 +273,20 @@ func (e *Error) HasAt() bool {
 // dependent value that further describes the error. For
 // example, in a keyword not recognized error, the context
 // is usually the offending keyword.
+//
+// If errors are recording their Go call stacks and this error has none,
+// such as a predefined error, the context is set on a copy of the error
+// that records the stack of the caller.
 func (e *Error) Context(context interface{}) *Error {
 	if e == nil {
 		return nil
 	}
 
+	if e.stack == nil && CapturingStacks() {
+		e = e.clone()
+		e.stack = callers()
+	}
+
 	if context != nil {
 		e.context = fmt.Sprintf("%v", context)
 	} else {
@@ -128,15 +305,33 @@ func (e *Error) Context(context interface{}) *Error {
 // error message is used. If the message starts with an "_"
 // character, no i18n translation is performed.
 func NewMessage(m string) *Error {
+	code := ""
+
 	if strings.HasPrefix(m, defs.ReadonlyVariablePrefix) {
 		m = m[1:]
-	} else {
-		m = i18n.E(m)
+		code = m
+	} else if text := i18n.E(m); text != m {
+		code = m
+		m = text
 	}
 
-	return &Error{
-		err: goerror.New(m),
+	e := &Error{
+		err:   goerror.New(m),
+		code:  code,
+		stack: callers(),
+	}
+
+	// The first error created for a code is the predefined one.
+	if code != "" {
+		codeMutex.Lock()
+		defer codeMutex.Unlock()
+
+		if _, found := codes[code]; !found {
+			codes[code] = e
+		}
 	}
+
+	return e
 }
 
 // Is compares the current error to the supplied error, and
@@ -148,13 +343,52 @@ func (e *Error) Is(err error) bool {
 
 	// Is the test error one of the Ego "native" errors? If so
 	// we need to compare both underlying error states.
-	if e1, ok := err.(*Error); ok {
-		return e.err == e1.err
+	if e1, ok := err.(*Error); ok && e.err == e1.err {
+		return true
+	}
+
+	// Otherwise, compare our underlying error to the provided
+	// error, which may be a Go native error.
+	if e.err == err {
+		return true
+	}
+
+	// An error read from JSON is the same as a predefined error with the
+	// same code.
+	if e1, ok := err.(*Error); ok && e.code != "" && e.code == e1.code {
+		return true
 	}
 
-	// Otherwise, we're comparing against a Go native error, so
-	// we compare our underlying error to the provided error.
-	return e.err == err
+	// Finally, check any errors wrapped by the underlying error, and the
+	// errors that caused this one.
+	if goerror.Is(e.err, err) {
+		return true
+	}
+
+	for _, cause := range e.causes {
+		if goerror.Is(cause, err) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first of the errors that caused this one that matches the
+// target, and if one is found, sets the target to that error. This allows
+// the standard library's errors.As() to search the causes.
+func (e *Error) As(target interface{}) bool {
+	if e == nil {
+		return false
+	}
+
+	for _, cause := range e.causes {
+		if goerror.As(cause, target) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func Equals(e1, e2 error) bool {
@@ -221,6 +455,11 @@ func Nil(e error) bool {
 
 // Format an EgoError as a string for human consumption.
 func (e *Error) Error() string {
+	return e.text(true)
+}
+
+// text formats the error, optionally followed by the errors that caused it.
+func (e *Error) text(causes bool) string {
 	var b strings.Builder
 
 	if e == nil || e.err == nil {
@@ -269,10 +508,9 @@ func (e *Error) Error() string {
 				b.WriteString(", ")
 			}
 
-			msg := strings.TrimPrefix(e.err.Error(), "error.")
 			predicate = true
 
-			b.WriteString(msg)
+			b.WriteString(e.message())
 		}
 	}
 
@@ -285,9 +523,51 @@ func (e *Error) Error() string {
 		b.WriteString(e.context)
 	}
 
+	// If we have structured context values, report them in key order.
+	if len(e.fields) > 0 {
+		keys := make([]string, 0, len(e.fields))
+		for key := range e.fields {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for n, key := range keys {
+			keys[n] = fmt.Sprintf("%s=%v", key, e.fields[key])
+		}
+
+		b.WriteString(" (" + strings.Join(keys, ", ") + ")")
+	}
+
+	// If we have causes, report them after the error.
+	if causes && len(e.causes) > 0 {
+		causes := make([]string, len(e.causes))
+		for n, cause := range e.causes {
+			causes[n] = cause.Error()
+		}
+
+		if b.Len() > 0 {
+			b.WriteString(": ")
+		}
+
+		b.WriteString(strings.Join(causes, "; "))
+	}
+
 	return b.String()
 }
 
+// message returns the text of the underlying error. If the error has a code,
+// the text is localized in the current language.
+func (e *Error) message() string {
+	if e.code != "" {
+		if text := i18n.E(e.code); text != e.code {
+			return text
+		}
+	}
+
+	return strings.TrimPrefix(e.err.Error(), "error.")
+}
+
 // Unwrap retrieves the native or wrapped error from this
 // Error.
 func (e *Error) Unwrap() error {
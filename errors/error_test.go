@@ -0,0 +1,157 @@
+package errors
+
+import (
+	"encoding/json"
+	goerror "errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "predefined error", err: ErrInvalidLogLevel, want: "log.level"},
+		{name: "with context", err: ErrInvalidLogSink.With("sink", "pipe"), want: "log.sink"},
+		{name: "unlocalized error", err: ErrContinue, want: "continue"},
+		{name: "native error", err: NewError(os.ErrNotExist), want: ""},
+		{name: "arbitrary message", err: NewMessage("something odd happened"), want: ""},
+		{name: "wrapped by a native error", err: wrapped{ErrInvalidLogLevel}, want: "log.level"},
+		{name: "nil", err: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Code(tt.err); got != tt.want {
+				t.Errorf("Code() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if ForCode("log.level") != ErrInvalidLogLevel || ForCode("no.such.code") != nil {
+		t.Errorf("ForCode() did not return the predefined error")
+	}
+}
+
+func TestWrapAndJoin(t *testing.T) {
+	err := ErrInvalidLogSink.With("sink", "pipe").Wrap(os.ErrPermission, nil)
+
+	if err == ErrInvalidLogSink || len(ErrInvalidLogSink.Causes()) > 0 || len(ErrInvalidLogSink.Fields()) > 0 {
+		t.Fatalf("Wrap() or With() changed the predefined error")
+	}
+
+	if got, want := err.Error(), "invalid log sink (sink=pipe): permission denied"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	if !goerror.Is(err, ErrInvalidLogSink) || !goerror.Is(err, fs.ErrPermission) || goerror.Is(err, fs.ErrNotExist) {
+		t.Errorf("errors.Is() did not match the error and its causes")
+	}
+
+	var pathError *fs.PathError
+
+	_, openError := os.Open("/no/such/file")
+	joined := Join(nil, ErrInvalidLogLevel, openError)
+
+	if !goerror.As(joined, &pathError) || pathError.Path != "/no/such/file" {
+		t.Errorf("errors.As() did not find the cause")
+	}
+
+	if !joined.Is(ErrMultipleErrors) || len(joined.Causes()) != 2 {
+		t.Errorf("Join() = %v", joined)
+	}
+
+	if Join(nil, nil) != nil || Join(nil, ErrInvalidLogLevel) != ErrInvalidLogLevel {
+		t.Errorf("Join() of zero or one error is not nil or the error")
+	}
+
+	// An error from the standard library's Join() keeps its causes.
+	native := NewError(goerror.Join(ErrInvalidLogLevel, os.ErrClosed))
+	if !native.Is(ErrMultipleErrors) || !goerror.Is(native, os.ErrClosed) || !goerror.Is(native, ErrInvalidLogLevel) {
+		t.Errorf("NewError() of a joined error = %v", native)
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	original := ErrInvalidLogLevel.With("logger", "server").In("settings").At(12, 4).
+		Wrap(ErrInvalidLogSink, os.ErrNotExist)
+
+	b, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := &Error{}
+	if err := json.Unmarshal(b, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Error() != original.Error() {
+		t.Errorf("round trip changed the text from %q to %q", original.Error(), result.Error())
+	}
+
+	if !result.Is(ErrInvalidLogLevel) || !result.Equal(ErrInvalidLogLevel) || !goerror.Is(result, ErrInvalidLogSink) {
+		t.Errorf("round trip error does not match the predefined errors: %s", string(b))
+	}
+
+	if !reflect.DeepEqual(result.Fields(), map[string]interface{}{"logger": "server"}) {
+		t.Errorf("round trip changed the fields to %v", result.Fields())
+	}
+
+	// An error with an unknown code keeps its code and message.
+	unknown := &Error{}
+	if err := json.Unmarshal([]byte(`{"code":"server.only","msg":"server failure","context":"disk"}`), unknown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if unknown.Code() != "server.only" || unknown.Error() != "server failure: disk" {
+		t.Errorf("unknown code read as %q, %q", unknown.Code(), unknown.Error())
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	CaptureStacks(true)
+	defer CaptureStacks(false)
+
+	err := ErrInvalidLogSink.Context("pipe").Wrap(os.ErrPermission)
+
+	if ErrInvalidLogSink.stack != nil || len(ErrInvalidLogSink.Causes()) > 0 {
+		t.Fatalf("Context() changed the predefined error while capturing stacks")
+	}
+
+	frames := err.Stack()
+	if len(frames) == 0 || !strings.HasSuffix(frames[0].Function, ".TestVerbose") {
+		t.Fatalf("Stack() does not start with the caller: %v", frames)
+	}
+
+	text := Verbose(err)
+	for _, want := range []string{
+		"invalid log sink: pipe\n",
+		"  code: log.sink\n",
+		"  stack:\n    github.com/tucats/gopackages/errors.TestVerbose\n",
+		"  caused by:\n    permission denied\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Verbose() does not contain %q:\n%s", want, text)
+		}
+	}
+
+	CaptureStacks(false)
+
+	if err := ErrInvalidLogSink.With("sink", "pipe"); len(err.Stack()) > 0 || Verbose(err) != "invalid log sink (sink=pipe)\n  code: log.sink\n" {
+		t.Errorf("Verbose() without a stack = %q", Verbose(err))
+	}
+}
+
+// wrapped is a native error that wraps another.
+type wrapped struct {
+	err error
+}
+
+func (w wrapped) Error() string { return "wrapped: " + w.err.Error() }
+func (w wrapped) Unwrap() error { return w.err }
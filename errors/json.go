@@ -0,0 +1,113 @@
+package errors
+
+import (
+	"encoding/json"
+	goerror "errors"
+)
+
+// errorJSON is the JSON form of an Error.
+type errorJSON struct {
+	Code     string                 `json:"code,omitempty"`
+	Message  string                 `json:"msg"`
+	Context  string                 `json:"context,omitempty"`
+	Location *locationJSON          `json:"location,omitempty"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+	Causes   []errorJSON            `json:"causes,omitempty"`
+}
+
+// locationJSON is the JSON form of the location of an Error.
+type locationJSON struct {
+	Name   string `json:"name,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// MarshalJSON converts the error to JSON, including its code, message,
+// context, location, context values, and causes. Causes that are not Error
+// values are represented by their message.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.toJSON())
+}
+
+// UnmarshalJSON reads an error from JSON. If the code is one of the predefined
+// errors, the result is the same as that error, so Is() and Equal() match it
+// and its message is in the current language. Otherwise the message from the
+// JSON is used.
+func (e *Error) UnmarshalJSON(b []byte) error {
+	data := errorJSON{}
+
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+
+	*e = *fromJSON(data)
+
+	return nil
+}
+
+// toJSON returns the JSON form of the error.
+func (e *Error) toJSON() errorJSON {
+	if e == nil || e.err == nil {
+		return errorJSON{}
+	}
+
+	data := errorJSON{
+		Code:    e.code,
+		Message: e.message(),
+		Context: e.context,
+	}
+
+	if e.location != nil {
+		data.Location = &locationJSON{
+			Name:   e.location.name,
+			Line:   e.location.line,
+			Column: e.location.column,
+		}
+	}
+
+	if len(e.fields) > 0 {
+		data.Fields = e.Fields()
+	}
+
+	for _, cause := range e.causes {
+		if c, ok := cause.(*Error); ok {
+			data.Causes = append(data.Causes, c.toJSON())
+		} else {
+			data.Causes = append(data.Causes, errorJSON{Message: cause.Error()})
+		}
+	}
+
+	return data
+}
+
+// fromJSON creates an error from its JSON form.
+func fromJSON(data errorJSON) *Error {
+	e := &Error{
+		code:    data.Code,
+		context: data.Context,
+	}
+
+	if predefined := ForCode(data.Code); predefined != nil {
+		e.err = predefined.err
+	} else {
+		e.err = goerror.New(data.Message)
+	}
+
+	if data.Location != nil {
+		e.location = &location{
+			name:   data.Location.Name,
+			line:   data.Location.Line,
+			column: data.Location.Column,
+		}
+	}
+
+	if len(data.Fields) > 0 {
+		e.fields = data.Fields
+	}
+
+	for _, cause := range data.Causes {
+		e.causes = append(e.causes, fromJSON(cause))
+	}
+
+	return e
+}
@@ -51,6 +51,8 @@ var ErrInvalidBooleanValue = NewMessage("boolean.option")
 var ErrInvalidBreakClause = NewMessage("break.clause")
 var ErrInvalidBytecodeAddress = NewMessage("bytecode.address")
 var ErrInvalidCallFrame = NewMessage("call.frame")
+var ErrInvalidCassetteMode = NewMessage("rest.cassette.mode")
+var ErrInvalidCatalog = NewMessage("catalog")
 var ErrInvalidChannel = NewMessage("not.channel")
 var ErrInvalidChannelList = NewMessage("channel.assignment")
 var ErrInvalidColumnDefinition = NewMessage("db.column.def")
@@ -68,18 +70,23 @@ var ErrInvalidFormatVerb = NewMessage("format.spec")
 var ErrInvalidFunctionArgument = NewMessage("func.arg")
 var ErrInvalidFunctionCall = NewMessage("func.call")
 var ErrInvalidFunctionName = NewMessage("func.name")
+var ErrInvalidHistoryVersion = NewMessage("history.version")
 var ErrInvalidIdentifier = NewMessage("identifier")
 var ErrInvalidImport = NewMessage("import")
 var ErrInvalidInstruction = NewMessage("instruction")
 var ErrInvalidInteger = NewMessage("integer.option")
 var ErrInvalidKeyword = NewMessage("keyword.option")
+var ErrInvalidLanguage = NewMessage("language")
 var ErrInvalidList = NewMessage("list")
+var ErrInvalidLogLevel = NewMessage("log.level")
+var ErrInvalidLogSink = NewMessage("log.sink")
 var ErrInvalidLoggerName = NewMessage("logger.name")
 var ErrInvalidLoopControl = NewMessage("loop.control")
 var ErrInvalidLoopIndex = NewMessage("loop.index")
 var ErrInvalidMediaType = NewMessage("media.type")
 var ErrInvalidOutputFormat = NewMessage("format.type")
 var ErrInvalidPackageName = NewMessage("package.name")
+var ErrInvalidPageLink = NewMessage("rest.page.link")
 var ErrInvalidPointerType = NewMessage("pointer.type")
 var ErrInvalidRange = NewMessage("range")
 var ErrInvalidResultSetType = NewMessage("db.result.type")
@@ -112,6 +119,7 @@ var ErrLoggerConflict = NewMessage("logger.conflict")
 var ErrLogonEndpoint = NewMessage("logon.endpoint")
 var ErrLoopBody = NewMessage("for.body")
 var ErrLoopExit = NewMessage("for.exit")
+var ErrMethodNotAllowed = NewMessage("http.method")
 var ErrMissingAssignment = NewMessage("assignment")
 var ErrMissingBlock = NewMessage("block")
 var ErrMissingBracket = NewMessage("array.bracket")
@@ -141,12 +149,14 @@ var ErrMissingStatement = NewMessage("statement")
 var ErrMissingSymbol = NewMessage("symbol.name")
 var ErrMissingTerm = NewMessage("expression.term")
 var ErrMissingType = NewMessage("type.def")
+var ErrMultipleErrors = NewMessage("multiple")
 var ErrNilPointerReference = NewMessage("nil")
 var ErrNoCredentials = NewMessage("credentials.missing")
 var ErrNoInfo = NewMessage("no.info")
 var ErrNoFunctionReceiver = NewMessage("function.receiver")
 var ErrNoLogonServer = NewMessage("logon.server")
 var ErrNoMainPackage = NewMessage("no.main.package")
+var ErrNoLogFile = NewMessage("log.file")
 var ErrNoPrivilegeForOperation = NewMessage("privilege")
 var ErrNoSuchAsset = NewMessage("asset")
 var ErrNoSuchDebugService = NewMessage("debug.service")
@@ -158,9 +168,11 @@ var ErrNoSymbolTable = NewMessage("no.symbol.table")
 var ErrNoTransactionActive = NewMessage("tx.not.active")
 var ErrNotAPointer = NewMessage("not.pointer")
 var ErrNotAService = NewMessage("not.service")
+var ErrNotLoggedOn = NewMessage("not.logged.on")
 var ErrNotAType = NewMessage("not.type")
 var ErrNotAnLValueList = NewMessage("not.assignment.list")
 var ErrNotFound = NewMessage("not.found")
+var ErrOAuthNotConfigured = NewMessage("oauth.config")
 var ErrOpcodeAlreadyDefined = NewMessage("opcode.defined")
 var ErrPackageRedefinition = NewMessage("package.exists")
 var ErrPanic = NewMessage("panic")
@@ -169,6 +181,7 @@ var ErrReadOnlyValue = NewMessage("readonly.write")
 var ErrRequiredNotFound = NewMessage("option.required")
 var ErrReservedProfileSetting = NewMessage("reserved.name")
 var ErrRestClientClosed = NewMessage("rest.closed")
+var ErrRestNoRecording = NewMessage("rest.recording")
 var ErrReturnValueCount = NewMessage("func.return.count")
 var ErrServerAlreadyRunning = NewMessage("server.running")
 var ErrStackUnderflow = NewMessage("stack.underflow")
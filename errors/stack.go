@@ -0,0 +1,134 @@
+package errors
+
+import (
+	goerror "errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/tucats/gopackages/i18n"
+)
+
+// The maximum number of Go call stack frames recorded for an error.
+const maxStackDepth = 32
+
+// Non-zero if errors record the Go call stack where they are created.
+var captureStacks int32
+
+// CaptureStacks sets whether errors record the Go call stack where they are
+// created. This is off by default, because it makes creating an error more
+// expensive. The stack is included in the report produced by Verbose().
+//
+// The stack is recorded by NewError(), NewMessage(), With(), and Wrap(). The
+// predefined errors are created before this can be turned on, so while it is
+// on, Context() returns a copy of an error that has no stack, which records
+// the stack of its caller.
+func CaptureStacks(flag bool) {
+	value := int32(0)
+	if flag {
+		value = 1
+	}
+
+	atomic.StoreInt32(&captureStacks, value)
+}
+
+// CapturingStacks returns true if errors record the Go call stack where they
+// are created.
+func CapturingStacks() bool {
+	return atomic.LoadInt32(&captureStacks) != 0
+}
+
+// callers returns the Go call stack of the caller of the function that calls
+// it, or nil if stacks are not being captured.
+func callers() []uintptr {
+	if !CapturingStacks() {
+		return nil
+	}
+
+	pcs := make([]uintptr, maxStackDepth)
+
+	// Skip runtime.Callers(), this function, and the function that called it.
+	n := runtime.Callers(3, pcs)
+
+	return pcs[:n]
+}
+
+// Stack returns the Go call stack where the error was created, most recent
+// call first. This is empty unless CaptureStacks(true) was in effect when the
+// error was created.
+func (e *Error) Stack() []runtime.Frame {
+	if e == nil || len(e.stack) == 0 {
+		return nil
+	}
+
+	result := make([]runtime.Frame, 0, len(e.stack))
+	frames := runtime.CallersFrames(e.stack)
+
+	for {
+		frame, more := frames.Next()
+		result = append(result, frame)
+
+		if !more {
+			break
+		}
+	}
+
+	return result
+}
+
+// Verbose formats an error for a bug report. Unlike Error(), which returns a
+// single line of text, each of the errors that caused it is on a separate,
+// indented line, and each error is followed by its code and, if it was
+// recorded, the Go call stack where it was created.
+func Verbose(err error) string {
+	if Nil(err) {
+		return ""
+	}
+
+	var b strings.Builder
+
+	writeVerbose(&b, err, "")
+
+	return b.String()
+}
+
+// writeVerbose writes the verbose form of an error, and then of each of its
+// causes, with the given indentation.
+func writeVerbose(b *strings.Builder, err error, indent string) {
+	e, ok := err.(*Error)
+	if !ok {
+		b.WriteString(indent + err.Error() + "\n")
+
+		// A native error may wrap one of ours, which can have a stack.
+		if inner, found := goerror.Unwrap(err).(*Error); found && inner != nil {
+			writeCause(b, inner, indent)
+		}
+
+		return
+	}
+
+	b.WriteString(indent + e.text(false) + "\n")
+
+	if e.code != "" {
+		b.WriteString(fmt.Sprintf("%s  %s: %s\n", indent, i18n.L("error.code"), e.code))
+	}
+
+	if frames := e.Stack(); len(frames) > 0 {
+		b.WriteString(fmt.Sprintf("%s  %s:\n", indent, i18n.L("error.stack")))
+
+		for _, frame := range frames {
+			b.WriteString(fmt.Sprintf("%s    %s\n%s        %s:%d\n", indent, frame.Function, indent, frame.File, frame.Line))
+		}
+	}
+
+	for _, cause := range e.causes {
+		writeCause(b, cause, indent)
+	}
+}
+
+// writeCause writes the verbose form of an error that caused another.
+func writeCause(b *strings.Builder, cause error, indent string) {
+	b.WriteString(fmt.Sprintf("%s  %s:\n", indent, i18n.L("error.cause")))
+	writeVerbose(b, cause, indent+"    ")
+}
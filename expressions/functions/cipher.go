@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/tucats/gopackages/app-cli/persistence"
+	"github.com/tucats/gopackages/app-cli/settings"
 	"github.com/tucats/gopackages/expressions/symbols"
 	"github.com/tucats/gopackages/util"
 )
@@ -175,7 +175,7 @@ func CreateToken(s *symbols.SymbolTable, args []interface{}) (interface{}, error
 
 	// Fetch the default interval, or use 15 minutes as the default.
 	// Calculate a time value for when this token expires
-	interval := persistence.Get(TokenExpirationSetting)
+	interval := settings.Get(TokenExpirationSetting)
 	if interval == "" {
 		interval = "15m"
 	}
@@ -202,11 +202,11 @@ func CreateToken(s *symbols.SymbolTable, args []interface{}) (interface{}, error
 // getTokenKey fetches the key used to encrypt tokens. If it
 // was not already set up, a new random one is generated.
 func getTokenKey() string {
-	key := persistence.Get(TokenKeySetting)
+	key := settings.Get(TokenKeySetting)
 	if key == "" {
 		key = uuid.New().String() + "-" + uuid.New().String()
-		persistence.Set(TokenKeySetting, key)
-		_ = persistence.Save()
+		settings.Set(TokenKeySetting, key)
+		_ = settings.Save()
 	}
 	return key
 }
@@ -9,7 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/tucats/gopackages/app-cli/persistence"
+	"github.com/tucats/gopackages/app-cli/settings"
 	"github.com/tucats/gopackages/errors"
 	"github.com/tucats/gopackages/expressions/symbols"
 	"github.com/tucats/gopackages/util"
@@ -27,7 +27,7 @@ func Sleep(syms *symbols.SymbolTable, args []interface{}) (interface{}, error) {
 // ProfileGet implements the profile.get() function
 func ProfileGet(symbols *symbols.SymbolTable, args []interface{}) (interface{}, error) {
 	key := util.GetString(args[0])
-	return persistence.Get(key), nil
+	return settings.Get(key), nil
 
 }
 
@@ -39,24 +39,24 @@ func ProfileSet(symbols *symbols.SymbolTable, args []interface{}) (interface{},
 	// store the value for the key.
 	value := util.GetString(args[1])
 	if value == "" {
-		persistence.Delete(key)
+		_ = settings.Delete(key)
 	} else {
-		persistence.Set(key, value)
+		settings.Set(key, value)
 	}
 
-	return nil, persistence.Save()
+	return nil, settings.Save()
 }
 
 // ProfileDelete implements the profile.delete() function
 func ProfileDelete(symbols *symbols.SymbolTable, args []interface{}) (interface{}, error) {
 	key := util.GetString(args[0])
-	persistence.Delete(key)
+	_ = settings.Delete(key)
 	return nil, nil
 }
 
 // ProfileKeys implements the profile.keys() function
 func ProfileKeys(symbols *symbols.SymbolTable, args []interface{}) (interface{}, error) {
-	keys := persistence.Keys()
+	keys := settings.Keys()
 	result := make([]interface{}, len(keys))
 	for i, key := range keys {
 		result[i] = key
@@ -0,0 +1,568 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The file extensions of the message catalog formats.
+const (
+	JSONCatalogExtension = ".json"
+	TOMLCatalogExtension = ".toml"
+	POCatalogExtension   = ".po"
+)
+
+// The prefixes that the functions L, M, E, and O add to a key.
+var keyPrefixes = []string{"label.", "msg.", "error.", "opt."}
+
+// LoadCatalogs reads the message catalogs in a directory of a file system,
+// such as an embed.FS, and adds their localizations to the localization
+// database. Files are read in name order, so a later file replaces the
+// localizations of an earlier one.
+//
+// The language of a catalog is taken from its file name, which is either the
+// language, such as "fr.json" or "pt_BR.toml", or ends with it, such as
+// "messages.fr.po". Three formats are supported:
+//
+//   - JSON files contain an object whose fields are message keys and whose
+//     values are the localized text.
+//   - TOML files contain key = "text" pairs. A [table] header is prefixed to
+//     the keys that follow it, so "cache" in the table [app] is "app.cache".
+//   - gettext .po files contain msgid and msgstr pairs. If an entry has a
+//     msgctxt, that is the key instead of the msgid. Entries that are marked
+//     fuzzy or that have an empty msgstr are not used.
+//
+// Files with other extensions are ignored.
+func LoadCatalogs(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || catalogFormat(entry.Name()) == "" {
+			continue
+		}
+
+		if err := LoadCatalog(fsys, path.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadCatalogDirectory reads the message catalogs in a directory on disk. See
+// LoadCatalogs for the file names and formats. It is not an error if the
+// directory does not exist.
+func LoadCatalogDirectory(dir string) error {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil
+	}
+
+	return LoadCatalogs(os.DirFS(dir), ".")
+}
+
+// LoadCatalog reads a single message catalog file and adds its localizations
+// to the localization database.
+func LoadCatalog(fsys fs.FS, name string) error {
+	language := catalogLanguage(name)
+	if language == "" {
+		return fmt.Errorf("%s: file name does not include a language", name)
+	}
+
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+
+	var texts map[string]string
+
+	switch catalogFormat(name) {
+	case JSONCatalogExtension:
+		texts, err = parseJSONCatalog(b)
+
+	case TOMLCatalogExtension:
+		texts, err = parseTOMLCatalog(b)
+
+	case POCatalogExtension:
+		texts, language, err = parsePOCatalog(b, language)
+
+	default:
+		return fmt.Errorf("%s: unsupported message catalog format", name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	localizations := map[string]map[string]string{}
+	for key, text := range texts {
+		localizations[key] = map[string]string{language: text}
+	}
+
+	Register(localizations)
+
+	return nil
+}
+
+// Languages returns the languages that have localizations, in sorted order.
+func Languages() []string {
+	found := map[string]bool{}
+
+	for _, texts := range messages {
+		for language := range texts {
+			found[language] = true
+		}
+	}
+
+	return sortedKeys(found)
+}
+
+// MissingKeys returns the keys that have an English localization but none in
+// the given language, or in a language it falls back to other than English,
+// in sorted order. So a key with Portuguese text is not missing for "pt-BR".
+// No keys are missing for English, or for a variant of it such as "en-GB".
+func MissingKeys(language string) []string {
+	missing := map[string]bool{}
+	fallbacks := LanguageFallbacks(language)
+
+	// English is only removed when it is the default that every language
+	// falls back to, rather than the base of the language.
+	if base, _, _ := strings.Cut(fallbacks[0], "-"); base != "en" {
+		fallbacks = fallbacks[:len(fallbacks)-1]
+	}
+
+	for key, texts := range messages {
+		if _, found := texts["en"]; !found {
+			continue
+		}
+
+		found := false
+
+		for _, fallback := range fallbacks {
+			if _, found = texts[fallback]; found {
+				break
+			}
+		}
+
+		if !found {
+			missing[key] = true
+		}
+	}
+
+	return sortedKeys(missing)
+}
+
+// UnusedKeys returns the keys that have a localization in the given language
+// but are not used, in sorted order. A key is used if it is one of the given
+// literals, or if it is one of them after a "label.", "msg.", "error.", or
+// "opt." prefix is added, since that is what L, M, E, and O do.
+func UnusedKeys(language string, literals map[string]bool) []string {
+	unused := map[string]bool{}
+	language = canonicalLanguage(language)
+
+	for key, texts := range messages {
+		if _, found := texts[language]; !found || literals[key] {
+			continue
+		}
+
+		used := false
+
+		for _, prefix := range keyPrefixes {
+			if strings.HasPrefix(key, prefix) && literals[strings.TrimPrefix(key, prefix)] {
+				used = true
+
+				break
+			}
+		}
+
+		if !used {
+			unused[key] = true
+		}
+	}
+
+	return sortedKeys(unused)
+}
+
+// catalogFormat returns the extension of a message catalog file, or an empty
+// string if it is not a catalog.
+func catalogFormat(name string) string {
+	switch extension := strings.ToLower(path.Ext(name)); extension {
+	case JSONCatalogExtension, TOMLCatalogExtension, POCatalogExtension:
+		return extension
+	}
+
+	return ""
+}
+
+// catalogLanguage returns the language of a message catalog from its file
+// name, or an empty string if the name does not include one.
+func catalogLanguage(name string) string {
+	name = path.Base(name)
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+
+	language, _ := ParseLanguageTag(name)
+
+	return language
+}
+
+// parseJSONCatalog reads a JSON message catalog.
+func parseJSONCatalog(b []byte) (map[string]string, error) {
+	texts := map[string]string{}
+
+	if err := json.Unmarshal(b, &texts); err != nil {
+		return nil, err
+	}
+
+	return texts, nil
+}
+
+// parseTOMLCatalog reads a TOML message catalog. Only the parts of TOML that
+// a catalog needs are supported: comments, table headers, and string values
+// that are basic, literal, or multi-line basic strings.
+func parseTOMLCatalog(b []byte) (map[string]string, error) {
+	texts := map[string]string{}
+	table := ""
+	lines := strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+
+	for n := 0; n < len(lines); n++ {
+		lineNumber := n + 1
+		line := strings.TrimSpace(lines[n])
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: invalid table header", lineNumber)
+			}
+
+			name, rest, err := parseTOMLKey(line[1:end])
+			if err != nil || strings.TrimSpace(rest) != "" {
+				return nil, fmt.Errorf("line %d: invalid table header", lineNumber)
+			}
+
+			table = name
+
+			continue
+		}
+
+		key, rest, err := parseTOMLKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "=") {
+			return nil, fmt.Errorf("line %d: missing '='", lineNumber)
+		}
+
+		value := strings.TrimSpace(rest[1:])
+
+		var text string
+
+		switch {
+		case strings.HasPrefix(value, `"""`):
+			// A multi-line string continues until the closing quotes. A
+			// newline right after the opening quotes is not part of it.
+			value = strings.TrimPrefix(value[3:], "\n")
+			for !strings.Contains(value, `"""`) && n+1 < len(lines) {
+				n++
+				value = value + "\n" + lines[n]
+			}
+
+			end := strings.Index(value, `"""`)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", lineNumber)
+			}
+
+			if text, err = unquoteTOML(strings.TrimPrefix(value[:end], "\n")); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+
+		case strings.HasPrefix(value, `"`):
+			end := closingQuote(value)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", lineNumber)
+			}
+
+			if text, err = unquoteTOML(value[1:end]); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", lineNumber)
+			}
+
+			text = value[1 : end+1]
+
+		default:
+			return nil, fmt.Errorf("line %d: value is not a string", lineNumber)
+		}
+
+		if table != "" {
+			key = table + "." + key
+		}
+
+		texts[key] = text
+	}
+
+	return texts, nil
+}
+
+// parseTOMLKey reads a key, which is a dotted list of bare or quoted names,
+// from the start of the text. It returns the key and the text that follows.
+func parseTOMLKey(text string) (string, string, error) {
+	parts := []string{}
+	text = strings.TrimSpace(text)
+
+	for {
+		switch {
+		case strings.HasPrefix(text, `"`):
+			end := closingQuote(text)
+			if end < 0 {
+				return "", "", fmt.Errorf("unterminated key")
+			}
+
+			name, err := unquoteTOML(text[1:end])
+			if err != nil {
+				return "", "", err
+			}
+
+			parts = append(parts, name)
+			text = text[end+1:]
+
+		case strings.HasPrefix(text, "'"):
+			end := strings.Index(text[1:], "'")
+			if end < 0 {
+				return "", "", fmt.Errorf("unterminated key")
+			}
+
+			parts = append(parts, text[1:end+1])
+			text = text[end+2:]
+
+		default:
+			end := strings.IndexFunc(text, func(ch rune) bool {
+				return !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_' || ch == '-')
+			})
+			if end == 0 {
+				return "", "", fmt.Errorf("missing key")
+			}
+
+			if end < 0 {
+				end = len(text)
+			}
+
+			parts = append(parts, text[:end])
+			text = text[end:]
+		}
+
+		text = strings.TrimSpace(text)
+		if !strings.HasPrefix(text, ".") {
+			return strings.Join(parts, "."), text, nil
+		}
+
+		text = strings.TrimSpace(text[1:])
+	}
+}
+
+// closingQuote returns the position of the double quote that ends the string
+// that starts the text, or -1 if there is none.
+func closingQuote(text string) int {
+	for n := 1; n < len(text); n++ {
+		switch text[n] {
+		case '\\':
+			n++
+
+		case '"':
+			return n
+		}
+	}
+
+	return -1
+}
+
+// unquoteTOML replaces the escape sequences in the contents of a basic string.
+func unquoteTOML(text string) (string, error) {
+	b := strings.Builder{}
+
+	for n := 0; n < len(text); n++ {
+		switch text[n] {
+		case '"':
+			b.WriteString(`\"`)
+
+		case '\n':
+			b.WriteString(`\n`)
+
+		case '\\':
+			// An escaped character is copied as is, except for the TOML
+			// escape \e, which Go does not have.
+			switch {
+			case n+1 >= len(text):
+				b.WriteByte(text[n])
+
+			case text[n+1] == 'e':
+				b.WriteString(`\x1b`)
+
+			default:
+				b.WriteString(text[n : n+2])
+			}
+
+			n++
+
+		default:
+			b.WriteByte(text[n])
+		}
+	}
+
+	result, err := strconv.Unquote(`"` + b.String() + `"`)
+	if err != nil {
+		return "", fmt.Errorf("invalid string")
+	}
+
+	return result, nil
+}
+
+// parsePOCatalog reads a gettext message catalog. If the catalog header has a
+// Language field, that replaces the language from the file name.
+func parsePOCatalog(b []byte, language string) (map[string]string, string, error) {
+	texts := map[string]string{}
+
+	var (
+		context, id, text string
+		target            *string
+		fuzzy             bool
+		started           bool
+		hasText           bool
+	)
+
+	finish := func() {
+		if started && !fuzzy {
+			key := id
+			if context != "" {
+				key = context
+			}
+
+			if key == "" {
+				// This is the header, which may give the language.
+				for _, line := range strings.Split(text, "\n") {
+					if name, value, found := strings.Cut(line, ":"); found && strings.EqualFold(strings.TrimSpace(name), "Language") {
+						if value := catalogLanguage(strings.TrimSpace(value)); value != "" {
+							language = value
+						}
+					}
+				}
+			} else if text != "" {
+				texts[key] = text
+			}
+		}
+
+		context, id, text, target, fuzzy, started, hasText = "", "", "", nil, false, false, false
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+
+	for n, line := range lines {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+			continue
+
+		case strings.HasPrefix(line, "#,"):
+			if hasText {
+				finish()
+			}
+
+			fuzzy = strings.Contains(line, "fuzzy")
+
+			continue
+
+		case strings.HasPrefix(line, "#"):
+			continue
+		}
+
+		keyword, value, _ := strings.Cut(line, " ")
+
+		if strings.HasPrefix(line, `"`) {
+			keyword, value = "", line
+		}
+
+		var quoted string
+
+		if value = strings.TrimSpace(value); value != "" {
+			var err error
+			if quoted, err = strconv.Unquote(value); err != nil {
+				return nil, "", fmt.Errorf("line %d: invalid string", n+1)
+			}
+		}
+
+		switch keyword {
+		case "":
+			if target == nil {
+				return nil, "", fmt.Errorf("line %d: unexpected string", n+1)
+			}
+
+			*target += quoted
+
+		case "msgctxt":
+			if hasText {
+				finish()
+			}
+
+			started = true
+			context = quoted
+			target = &context
+
+		case "msgid":
+			if hasText {
+				finish()
+			}
+
+			started = true
+			id = quoted
+			target = &id
+
+		case "msgstr":
+			hasText = true
+			text = quoted
+			target = &text
+
+		default:
+			// Plural entries and other keywords are not used; plural forms
+			// are written with the {{count|plural ...}} placeholder.
+			target = nil
+		}
+	}
+
+	finish()
+
+	return texts, language, nil
+}
+
+// sortedKeys returns the keys of a set in sorted order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
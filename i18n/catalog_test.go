@@ -0,0 +1,143 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadCatalogs(t *testing.T) {
+	savedLanguage := Language
+	defer func() { Language = savedLanguage }()
+
+	fsys := fstest.MapFS{
+		"catalogs/de.json": {Data: []byte(`{
+			"app.cache": "Den Cache der REST-Antworten verwalten",
+			"test.catalog.rows": "{{count|plural one{# Zeile} other{# Zeilen}}}"
+		}`)},
+		"catalogs/messages.es.toml": {Data: []byte(`# Spanish catalog
+"app.cache" = "Administrar la caché"
+
+[test.catalog]
+rows = "{{count|plural one{# fila} other{# filas}}}"
+quote = 'dijo "hola"'
+escaped = "línea uno\nlínea \"dos\""
+long = """
+primera
+segunda"""
+`)},
+		"catalogs/fr_CA.po": {Data: []byte(`# French catalog
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Language: fr\n"
+
+msgctxt "app.cache"
+msgid "Manage the cache of REST responses"
+msgstr "Gérer le cache "
+"des réponses REST"
+
+#, fuzzy
+msgid "test.catalog.rows"
+msgstr "{{count}} lignes"
+
+msgid "test.catalog.empty"
+msgstr ""
+
+msgid "test.catalog.quote"
+msgstr "il a dit \"bonjour\""
+`)},
+		"catalogs/README.md": {Data: []byte("not a catalog")},
+	}
+
+	if err := LoadCatalogs(fsys, "catalogs"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		language string
+		key      string
+		values   map[string]interface{}
+		want     string
+	}{
+		{"de", "app.cache", nil, "Den Cache der REST-Antworten verwalten"},
+		{"de", "test.catalog.rows", map[string]interface{}{"count": 1}, "1 Zeile"},
+		{"es", "app.cache", nil, "Administrar la caché"},
+		{"es", "test.catalog.rows", map[string]interface{}{"count": 2}, "2 filas"},
+		{"es", "test.catalog.quote", nil, `dijo "hola"`},
+		{"es", "test.catalog.escaped", nil, "línea uno\nlínea \"dos\""},
+		{"es", "test.catalog.long", nil, "primera\nsegunda"},
+		{"fr", "app.cache", nil, "Gérer le cache des réponses REST"},
+		{"fr", "test.catalog.quote", nil, `il a dit "bonjour"`},
+		{"fr", "test.catalog.rows", map[string]interface{}{"count": 2}, "test.catalog.rows"},
+		{"fr", "test.catalog.empty", nil, "test.catalog.empty"},
+	}
+
+	for _, tt := range tests {
+		Language = tt.language
+
+		var got string
+		if tt.values == nil {
+			got = T(tt.key)
+		} else {
+			got = T(tt.key, tt.values)
+		}
+
+		if got != tt.want {
+			t.Errorf("T(%s) in %s = %q, want %q", tt.key, tt.language, got, tt.want)
+		}
+	}
+
+	bad := fstest.MapFS{
+		"messages.toml": {Data: []byte(`key = "value"`)},
+		"xx.toml":       {Data: []byte(`key = 42`)},
+	}
+
+	if err := LoadCatalog(bad, "messages.toml"); err == nil {
+		t.Errorf("catalog without a language was accepted")
+	}
+
+	if err := LoadCatalog(bad, "xx.toml"); err == nil {
+		t.Errorf("catalog with a value that is not a string was accepted")
+	}
+}
+
+func TestCatalogReports(t *testing.T) {
+	Register(map[string]map[string]string{
+		"label.report.used":   {"en": "used", "zz": "used"},
+		"msg.report.unused":   {"en": "unused", "zz": "unused"},
+		"report.only.english": {"en": "english"},
+		"report.only.zz":      {"zz": "zz"},
+	})
+
+	if got := MissingKeys("zz"); !contains(got, "report.only.english") || contains(got, "label.report.used") {
+		t.Errorf("MissingKeys() returned %v", got)
+	}
+
+	for _, language := range []string{"en", "en-GB"} {
+		if got := MissingKeys(language); len(got) > 0 {
+			t.Errorf("MissingKeys(%q) returned %v", language, got)
+		}
+	}
+
+	literals := map[string]bool{"report.used": true, "report.only.zz": true}
+
+	got := UnusedKeys("zz", literals)
+	if !reflect.DeepEqual(got, []string{"msg.report.unused"}) {
+		t.Errorf("UnusedKeys() = %v", got)
+	}
+
+	if !contains(Languages(), "zz") {
+		t.Errorf("Languages() = %v", Languages())
+	}
+}
+
+func contains(list []string, item string) bool {
+	for _, element := range list {
+		if element == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,238 @@
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localeFormat describes how numbers, dates, and times are written in a
+// language.
+type localeFormat struct {
+	decimal string
+	group   string
+	date    string
+	time    string
+}
+
+// The number, date, and time conventions for each language, and for regions
+// whose conventions differ from those of their language. A language that is
+// not in this table uses the "en" conventions. Languages that group digits
+// with a space use a non-breaking space.
+var localeFormats = map[string]localeFormat{
+	"cs":    {decimal: ",", group: "\u00a0", date: "2. 1. 2006", time: "15:04:05"},
+	"da":    {decimal: ",", group: ".", date: "02.01.2006", time: "15.04.05"},
+	"de":    {decimal: ",", group: ".", date: "02.01.2006", time: "15:04:05"},
+	"de-CH": {decimal: ".", group: "’", date: "02.01.2006", time: "15:04:05"},
+	"en":    {decimal: ".", group: ",", date: "01/02/2006", time: "3:04:05 PM"},
+	"en-AU": {decimal: ".", group: ",", date: "02/01/2006", time: "3:04:05 PM"},
+	"en-CA": {decimal: ".", group: ",", date: "2006-01-02", time: "3:04:05 PM"},
+	"en-GB": {decimal: ".", group: ",", date: "02/01/2006", time: "15:04:05"},
+	"es":    {decimal: ",", group: ".", date: "02/01/2006", time: "15:04:05"},
+	"es-MX": {decimal: ".", group: ",", date: "02/01/2006", time: "15:04:05"},
+	"fi":    {decimal: ",", group: "\u00a0", date: "2.1.2006", time: "15.04.05"},
+	"fr":    {decimal: ",", group: "\u00a0", date: "02/01/2006", time: "15:04:05"},
+	"fr-CA": {decimal: ",", group: "\u00a0", date: "2006-01-02", time: "15:04:05"},
+	"fr-CH": {decimal: ",", group: "\u00a0", date: "02.01.2006", time: "15:04:05"},
+	"it":    {decimal: ",", group: ".", date: "02/01/2006", time: "15:04:05"},
+	"ja":    {decimal: ".", group: ",", date: "2006/01/02", time: "15:04:05"},
+	"ko":    {decimal: ".", group: ",", date: "2006. 01. 02.", time: "15:04:05"},
+	"nl":    {decimal: ",", group: ".", date: "02-01-2006", time: "15:04:05"},
+	"no":    {decimal: ",", group: "\u00a0", date: "02.01.2006", time: "15:04:05"},
+	"pl":    {decimal: ",", group: "\u00a0", date: "02.01.2006", time: "15:04:05"},
+	"pt":    {decimal: ",", group: ".", date: "02/01/2006", time: "15:04:05"},
+	"pt-PT": {decimal: ",", group: "\u00a0", date: "02/01/2006", time: "15:04:05"},
+	"ru":    {decimal: ",", group: "\u00a0", date: "02.01.2006", time: "15:04:05"},
+	"sv":    {decimal: ",", group: "\u00a0", date: "2006-01-02", time: "15:04:05"},
+	"uk":    {decimal: ",", group: "\u00a0", date: "02.01.2006", time: "15:04:05"},
+	"zh":    {decimal: ".", group: ",", date: "2006/01/02", time: "15:04:05"},
+}
+
+// locale returns the formatting conventions for the current language, or the
+// first language it falls back to that has them.
+func locale() localeFormat {
+	for _, language := range languages() {
+		if format, found := localeFormats[language]; found {
+			return format
+		}
+	}
+
+	return localeFormats["en"]
+}
+
+// FormatNumber formats an integer or floating point value using the digit
+// grouping and decimal separator of the current language. Floating point
+// values are shown with as many decimal places as are needed. Any other
+// value is formatted with %v.
+func FormatNumber(value interface{}) string {
+	if i, ok := integerValue(value); ok {
+		return FormatInteger(i)
+	}
+
+	if f, ok := floatValue(value); ok {
+		return FormatFloat(f, -1)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// FormatInteger formats an integer using the digit grouping of the current
+// language, such as "1,234,567" in English or "1.234.567" in German.
+func FormatInteger(value int64) string {
+	text := strconv.FormatInt(value, 10)
+	sign := ""
+
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+
+	return sign + groupDigits(text, locale().group)
+}
+
+// FormatFloat formats a floating point value using the digit grouping and
+// decimal separator of the current language. If decimals is negative, as many
+// decimal places as are needed are shown.
+func FormatFloat(value float64, decimals int) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	format := locale()
+	text := strconv.FormatFloat(value, 'f', decimals, 64)
+	sign := ""
+
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+
+	whole, fraction, found := strings.Cut(text, ".")
+
+	text = sign + groupDigits(whole, format.group)
+	if found {
+		text = text + format.decimal + fraction
+	}
+
+	return text
+}
+
+// FormatDate formats the date part of a time using the conventions of the
+// current language.
+func FormatDate(t time.Time) string {
+	return t.Format(locale().date)
+}
+
+// FormatTime formats the time of day part of a time using the conventions
+// of the current language.
+func FormatTime(t time.Time) string {
+	return t.Format(locale().time)
+}
+
+// FormatDateTime formats a time, with both the date and the time of day,
+// using the conventions of the current language.
+func FormatDateTime(t time.Time) string {
+	format := locale()
+
+	return t.Format(format.date + " " + format.time)
+}
+
+// FormatDuration formats a duration as a list of units in the current
+// language, such as "2 hours, 5 minutes, 1 second". Durations of a second or
+// more are shown to the nearest second; shorter durations are shown in
+// milliseconds.
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	if d < time.Second {
+		return sign + T("unit.milliseconds", map[string]interface{}{"count": d.Milliseconds()})
+	}
+
+	d = d.Round(time.Second)
+
+	units := []struct {
+		key  string
+		size time.Duration
+	}{
+		{"unit.days", 24 * time.Hour},
+		{"unit.hours", time.Hour},
+		{"unit.minutes", time.Minute},
+		{"unit.seconds", time.Second},
+	}
+
+	parts := []string{}
+
+	for _, unit := range units {
+		if count := d / unit.size; count > 0 {
+			parts = append(parts, T(unit.key, map[string]interface{}{"count": int64(count)}))
+			d -= count * unit.size
+		}
+	}
+
+	return sign + strings.Join(parts, T("unit.separator"))
+}
+
+// groupDigits inserts the group separator between each group of three
+// digits, counting from the right.
+func groupDigits(digits, separator string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	b := strings.Builder{}
+
+	first := len(digits) % 3
+	if first > 0 {
+		b.WriteString(digits[:first])
+	}
+
+	for n := first; n < len(digits); n += 3 {
+		if n > 0 {
+			b.WriteString(separator)
+		}
+
+		b.WriteString(digits[n : n+3])
+	}
+
+	return b.String()
+}
+
+// integerValue returns the value of any integer type as an int64.
+func integerValue(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() <= math.MaxInt64 {
+			return int64(v.Uint()), true
+		}
+	}
+
+	return 0, false
+}
+
+// floatValue returns the value of any numeric type as a float64.
+func floatValue(value interface{}) (float64, bool) {
+	if i, ok := integerValue(value); ok {
+		return float64(i), true
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+
+	case reflect.Uint, reflect.Uint64:
+		return float64(v.Uint()), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,190 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPluralCategory(t *testing.T) {
+	savedLanguage := Language
+	defer func() { Language = savedLanguage }()
+
+	tests := []struct {
+		language string
+		value    interface{}
+		want     string
+	}{
+		{"en", 1, PluralOne},
+		{"en", 0, PluralOther},
+		{"en", 1.5, PluralOther},
+		{"fr", 0, PluralOne},
+		{"fr", 1.5, PluralOne},
+		{"fr", 2, PluralOther},
+		{"ru", 21, PluralOne},
+		{"ru", 3, PluralFew},
+		{"ru", 11, PluralMany},
+		{"ru", 112, PluralMany},
+		{"pl", 22, PluralFew},
+		{"pl", 21, PluralMany},
+		{"cs", 4, PluralFew},
+		{"cs", 2.5, PluralMany},
+		{"ar", 0, PluralZero},
+		{"ar", 2, PluralTwo},
+		{"ar", 105, PluralFew},
+		{"ar", 111, PluralMany},
+		{"ja", 1, PluralOther},
+		{"xx", 1, PluralOne},
+		{"en", "one", PluralOther},
+	}
+
+	for _, tt := range tests {
+		Language = tt.language
+		if got := PluralCategory(tt.value); got != tt.want {
+			t.Errorf("PluralCategory(%v) in %s = %s, want %s", tt.value, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	savedLanguage := Language
+	defer func() { Language = savedLanguage }()
+
+	tests := []struct {
+		language string
+		value    interface{}
+		want     string
+	}{
+		{"en", 0, "0"},
+		{"en", 999, "999"},
+		{"en", 1234567, "1,234,567"},
+		{"en", -1234, "-1,234"},
+		{"en", uint8(200), "200"},
+		{"en", 1234.5, "1,234.5"},
+		{"de", 1234567.25, "1.234.567,25"},
+		{"fr", 123456, "123\u00a0456"},
+		{"en", "text", "text"},
+	}
+
+	for _, tt := range tests {
+		Language = tt.language
+		if got := FormatNumber(tt.value); got != tt.want {
+			t.Errorf("FormatNumber(%v) in %s = %q, want %q", tt.value, tt.language, got, tt.want)
+		}
+	}
+
+	Language = "de"
+	if got := FormatFloat(1234.5, 2); got != "1.234,50" {
+		t.Errorf("FormatFloat() = %q, want %q", got, "1.234,50")
+	}
+}
+
+func TestFormatTimes(t *testing.T) {
+	savedLanguage := Language
+	defer func() { Language = savedLanguage }()
+
+	when := time.Date(2024, 3, 7, 14, 5, 9, 0, time.UTC)
+
+	Language = "en"
+	if got := FormatDateTime(when); got != "03/07/2024 2:05:09 PM" {
+		t.Errorf("FormatDateTime() in en = %q", got)
+	}
+
+	Language = "de"
+	if got := FormatDate(when); got != "07.03.2024" {
+		t.Errorf("FormatDate() in de = %q", got)
+	}
+
+	Language = "en"
+	if got := FormatDuration(26*time.Hour + time.Minute + 1500*time.Millisecond); got != "1 day, 2 hours, 1 minute, 2 seconds" {
+		t.Errorf("FormatDuration() in en = %q", got)
+	}
+
+	if got := FormatDuration(250 * time.Millisecond); got != "250 milliseconds" {
+		t.Errorf("FormatDuration() in en = %q", got)
+	}
+
+	Language = "fr"
+	if got := FormatDuration(90 * time.Minute); got != "1 heure, 30 minutes" {
+		t.Errorf("FormatDuration() in fr = %q", got)
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	savedLanguage := Language
+	defer func() { Language = savedLanguage }()
+
+	Language = "en"
+
+	tests := []struct {
+		name   string
+		text   string
+		values map[string]interface{}
+		want   string
+	}{
+		{
+			name:   "simple value",
+			text:   "Table {{name}} deleted",
+			values: map[string]interface{}{"name": "users"},
+			want:   "Table users deleted",
+		},
+		{
+			name:   "missing value is unchanged",
+			text:   "Table {{name}} has {{count|number}} rows",
+			values: map[string]interface{}{"name": "users"},
+			want:   "Table users has {{count|number}} rows",
+		},
+		{
+			name:   "malformed placeholder",
+			text:   "version {{v}; count={{c}}",
+			values: map[string]interface{}{"v": 1, "c": 2},
+			want:   "version {{v}; count=2",
+		},
+		{
+			name:   "number",
+			text:   "{{count|number}} bytes, {{ratio|number:1}}%",
+			values: map[string]interface{}{"count": 10240, "ratio": 12.345},
+			want:   "10,240 bytes, 12.3%",
+		},
+		{
+			name:   "date and duration",
+			text:   "on {{when|date}} for {{elapsed|duration}}",
+			values: map[string]interface{}{"when": time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), "elapsed": 61 * time.Second},
+			want:   "on 03/07/2024 for 1 minute, 1 second",
+		},
+		{
+			name:   "plural one",
+			text:   "{{count|plural one{# row} other{# rows}}} deleted",
+			values: map[string]interface{}{"count": 1},
+			want:   "1 row deleted",
+		},
+		{
+			name:   "plural other",
+			text:   "{{count|plural one{# row} other{# rows}}} deleted",
+			values: map[string]interface{}{"count": 1500},
+			want:   "1,500 rows deleted",
+		},
+		{
+			name:   "plural exact value and nested placeholder",
+			text:   "{{count|plural =0{no rows in {{table}}} one{# row in {{table}}} other{# rows in {{table}}}}}",
+			values: map[string]interface{}{"count": 0, "table": "users"},
+			want:   "no rows in users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := substitute(tt.text, tt.values); got != tt.want {
+				t.Errorf("substitute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	Language = "ru"
+
+	text := "{{count|plural one{# файл} few{# файла} many{# файлов} other{# файла}}}"
+	for count, want := range map[int]string{1: "1 файл", 3: "3 файла", 5: "5 файлов"} {
+		if got := substitute(text, map[string]interface{}{"count": count}); got != want {
+			t.Errorf("substitute() in ru = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package i18n
+
+import (
+	"os"
+	"strings"
+)
+
+// The environment variables that give the language, in order of precedence.
+// APP_LANG is specific to applications that use this package; the others are
+// the POSIX locale variables.
+var languageVariables = []string{"APP_LANG", "LC_ALL", "LC_MESSAGES", "LANG"}
+
+// The scripts implied by the regions of languages that are written in more
+// than one script, so "zh-TW" also finds localizations for "zh-Hant".
+var regionScripts = map[string]string{
+	"zh-CN": "Hans",
+	"zh-SG": "Hans",
+	"zh-HK": "Hant",
+	"zh-MO": "Hant",
+	"zh-TW": "Hant",
+}
+
+// SetLanguage sets the current language from a BCP 47 language tag, such as
+// "pt-BR", or a POSIX locale name, such as "pt_BR.UTF-8". The result is false,
+// and the language is unchanged, if the text is not a valid tag.
+func SetLanguage(text string) bool {
+	tag, ok := ParseLanguageTag(text)
+	if ok {
+		Language = tag
+	}
+
+	return ok
+}
+
+// ParseLanguageTag converts a BCP 47 language tag, or a POSIX locale name, to
+// its canonical form: a lower case language, an optional title case script,
+// an optional upper case region, and any variants, separated by hyphens. For
+// example, "zh_hant_tw" becomes "zh-Hant-TW", and "pt_BR.UTF-8" becomes
+// "pt-BR". Extensions and private use subtags are removed. The POSIX locales
+// "C" and "POSIX" are English. The result is false if the text is not a valid
+// tag.
+func ParseLanguageTag(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+
+	// Remove the codeset and modifier of a POSIX locale name.
+	if end := strings.IndexAny(text, ".@"); end >= 0 {
+		text = text[:end]
+	}
+
+	if text == "C" || text == "POSIX" {
+		return "en", true
+	}
+
+	subtags := strings.FieldsFunc(text, func(ch rune) bool {
+		return ch == '-' || ch == '_'
+	})
+
+	if len(subtags) == 0 || !isAlpha(subtags[0]) || len(subtags[0]) < 2 || len(subtags[0]) > 3 {
+		return "", false
+	}
+
+	parts := []string{strings.ToLower(subtags[0])}
+	hasScript, hasRegion := false, false
+
+	for _, subtag := range subtags[1:] {
+		switch {
+		case len(subtag) == 1:
+			// An extension or private use subtags follow; they do not
+			// affect the localizations that are chosen.
+			return strings.Join(parts, "-"), true
+
+		case len(subtag) == 4 && isAlpha(subtag) && !hasScript && !hasRegion:
+			parts = append(parts, strings.ToUpper(subtag[:1])+strings.ToLower(subtag[1:]))
+			hasScript = true
+
+		case (len(subtag) == 2 && isAlpha(subtag) || len(subtag) == 3 && isDigits(subtag)) && !hasRegion:
+			parts = append(parts, strings.ToUpper(subtag))
+			hasRegion = true
+
+		case len(subtag) >= 5 && len(subtag) <= 8 || len(subtag) == 4 && subtag[0] >= '0' && subtag[0] <= '9':
+			parts = append(parts, strings.ToLower(subtag))
+			hasScript, hasRegion = true, true
+
+		default:
+			return "", false
+		}
+	}
+
+	return strings.Join(parts, "-"), true
+}
+
+// LanguageFallbacks returns the languages whose localizations are used for a
+// language tag, most specific first. Each subtag is removed in turn, and
+// English is always last. For example, "pt-BR" falls back to "pt" and then
+// "en", and "zh-TW" falls back to "zh-Hant", "zh", and "en".
+func LanguageFallbacks(tag string) []string {
+	tag, ok := ParseLanguageTag(tag)
+	if !ok {
+		return []string{"en"}
+	}
+
+	result := []string{}
+	add := func(tag string) {
+		for _, existing := range result {
+			if existing == tag {
+				return
+			}
+		}
+
+		result = append(result, tag)
+	}
+
+	for {
+		add(tag)
+
+		if script, found := regionScripts[tag]; found {
+			language, _, _ := strings.Cut(tag, "-")
+			add(language + "-" + script)
+		}
+
+		end := strings.LastIndex(tag, "-")
+		if end < 0 {
+			break
+		}
+
+		tag = tag[:end]
+	}
+
+	add("en")
+
+	return result
+}
+
+// currentLanguage returns the current language. If it has not been set, it
+// is taken from the first environment variable that has a valid language, in
+// the order APP_LANG, LC_ALL, LC_MESSAGES, and LANG. If none does, it is "en".
+func currentLanguage() string {
+	if Language == "" {
+		Language = "en"
+
+		for _, name := range languageVariables {
+			if tag, ok := ParseLanguageTag(os.Getenv(name)); ok {
+				Language = tag
+
+				break
+			}
+		}
+	}
+
+	return Language
+}
+
+// languages returns the fallback languages for the current language.
+func languages() []string {
+	return LanguageFallbacks(currentLanguage())
+}
+
+// canonicalLanguage returns the canonical form of a language tag given to
+// Register or read from a catalog, or the text as-is if it is not valid.
+func canonicalLanguage(text string) string {
+	if tag, ok := ParseLanguageTag(text); ok {
+		return tag
+	}
+
+	return text
+}
+
+// isAlpha reports whether the text is only ASCII letters.
+func isAlpha(text string) bool {
+	for _, ch := range text {
+		if (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') {
+			return false
+		}
+	}
+
+	return text != ""
+}
+
+// isDigits reports whether the text is only ASCII digits.
+func isDigits(text string) bool {
+	for _, ch := range text {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+
+	return text != ""
+}
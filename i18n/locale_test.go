@@ -0,0 +1,107 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLanguageTag(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		ok   bool
+	}{
+		{"en", "en", true},
+		{"pt_BR", "pt-BR", true},
+		{"pt_BR.UTF-8", "pt-BR", true},
+		{"de_DE@euro", "de-DE", true},
+		{"ZH-hant-tw", "zh-Hant-TW", true},
+		{"es-419", "es-419", true},
+		{"sl-rozaj-biske", "sl-rozaj-biske", true},
+		{"en-US-u-ca-gregory", "en-US", true},
+		{"C.UTF-8", "en", true},
+		{"POSIX", "en", true},
+		{"", "", false},
+		{"x1", "", false},
+		{"english", "", false},
+		{"en-US-GB", "", false},
+		{"12", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseLanguageTag(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseLanguageTag(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLanguageFallbacks(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{"en", []string{"en"}},
+		{"pt-BR", []string{"pt-BR", "pt", "en"}},
+		{"zh-TW", []string{"zh-TW", "zh-Hant", "zh", "en"}},
+		{"zh-Hans-CN", []string{"zh-Hans-CN", "zh-Hans", "zh", "en"}},
+		{"not a tag", []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		if got := LanguageFallbacks(tt.tag); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LanguageFallbacks(%q) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageNegotiation(t *testing.T) {
+	savedLanguage := Language
+	defer func() { Language = savedLanguage }()
+
+	Register(map[string]map[string]string{
+		"test.locale.color": {"en": "color", "en_GB": "colour", "pt": "cor", "zh-Hant": "顏色"},
+	})
+
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"LANG": "en_GB.UTF-8"}, "colour"},
+		{map[string]string{"LANG": "pt_BR.UTF-8"}, "cor"},
+		{map[string]string{"LANG": "zh_TW.UTF-8"}, "顏色"},
+		{map[string]string{"LANG": "C.UTF-8"}, "color"},
+		{map[string]string{"LC_MESSAGES": "pt_PT", "LANG": "en_GB"}, "cor"},
+		{map[string]string{"LC_ALL": "en_GB", "LC_MESSAGES": "pt_PT"}, "colour"},
+		{map[string]string{"APP_LANG": "pt", "LC_ALL": "en_GB"}, "cor"},
+		{map[string]string{"LC_ALL": "", "LANG": "en_GB"}, "colour"},
+	}
+
+	for _, tt := range tests {
+		for _, name := range languageVariables {
+			t.Setenv(name, tt.env[name])
+		}
+
+		Language = ""
+
+		if got := T("test.locale.color"); got != tt.want {
+			t.Errorf("T() with %v = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+
+	if SetLanguage("not a tag") {
+		t.Errorf("SetLanguage() accepted an invalid tag")
+	}
+
+	if !SetLanguage("pt_BR") || Language != "pt-BR" || T("test.locale.color") != "cor" {
+		t.Errorf("SetLanguage() set the language to %q", Language)
+	}
+
+	if got := FormatNumber(1234.5); got != "1.234,5" {
+		t.Errorf("FormatNumber() in pt-BR = %q", got)
+	}
+
+	if got := MissingKeys("pt-BR"); contains(got, "test.locale.color") {
+		t.Errorf("MissingKeys() reported a key with text in a fallback language")
+	}
+}
@@ -8,18 +8,30 @@ package i18n
 // If the text isn't found in English either, the key is returned
 // as the unlocalizable result.
 var messages = map[string]map[string]string{
+	"app.cache": {
+		"en": "Manage the cache of REST responses",
+	},
+	"app.cache.clear": {
+		"en": "Remove all cached REST responses",
+	},
 	"app.config": {
 		"en": "View or set application configuration",
 	},
 	"app.config.delete": {
 		"en": "Delete an application configuration item",
 	},
+	"app.config.history": {
+		"en": "Show the change history of the current configuration",
+	},
 	"app.config.list": {
 		"en": "List the application configuration profiles",
 	},
 	"app.config.remove": {
 		"en": "Remove an application configuration profile",
 	},
+	"app.config.rollback": {
+		"en": "Restore the current configuration to a prior version",
+	},
 	"app.config.set": {
 		"en": "Set an application configuration item",
 	},
@@ -32,9 +44,18 @@ var messages = map[string]map[string]string{
 	"app.config.show": {
 		"en": "Show the application configuration items in the current profile",
 	},
+	"app.log": {
+		"en": "Show messages from the log file",
+	},
 	"app.logon": {
 		"en": "Log on to a remote server",
 	},
+	"app.logon.status": {
+		"en": "Show the logon status for each server",
+	},
+	"app.logout": {
+		"en": "Log off from a remote server",
+	},
 	"global.version": {
 		"en": "Show application version",
 	},
@@ -62,6 +83,9 @@ var messages = map[string]map[string]string{
 	"cert.parse.err": {
 		"en": "error parsing certficate file",
 	},
+	"error.catalog": {
+		"en": "invalid message catalog",
+	},
 	"error.cli.command.not.found": {
 		"en": "unrecognized command",
 	},
@@ -210,9 +234,15 @@ var messages = map[string]map[string]string{
 	"error.go.error": {
 		"en": "Go routine {{name}} failed, {{err}}",
 	},
+	"error.history.version": {
+		"en": "invalid configuration history version",
+	},
 	"error.http": {
 		"en": "received HTTP",
 	},
+	"error.http.method": {
+		"en": "method not allowed",
+	},
 	"error.identifier": {
 		"en": "invalid identifier",
 	},
@@ -249,9 +279,21 @@ var messages = map[string]map[string]string{
 	"error.keyword.option": {
 		"en": "invalid option keyword",
 	},
+	"error.language": {
+		"en": "invalid language tag",
+	},
 	"error.list": {
 		"en": "invalid list",
 	},
+	"error.log.file": {
+		"en": "no log file",
+	},
+	"error.log.level": {
+		"en": "invalid log level",
+	},
+	"error.log.sink": {
+		"en": "invalid log sink",
+	},
 	"error.logger.confict": {
 		"en": "conflicting logger state",
 	},
@@ -267,6 +309,9 @@ var messages = map[string]map[string]string{
 	"error.media.type": {
 		"en": "invalid media type",
 	},
+	"error.multiple": {
+		"en": "multiple errors",
+	},
 	"error.nil": {
 		"en": "nil pointer reference",
 	},
@@ -282,9 +327,15 @@ var messages = map[string]map[string]string{
 	"error.not.found": {
 		"en": "not found",
 	},
+	"error.not.logged.on": {
+		"en": "not logged on to server",
+	},
 	"error.not.type": {
 		"en": "not a type",
 	},
+	"error.oauth.config": {
+		"en": "OAuth2 authorization server not configured",
+	},
 	"error.opcode.defined": {
 		"en": "opcode already defined",
 	},
@@ -330,6 +381,15 @@ var messages = map[string]map[string]string{
 	"error.reserved.name": {
 		"en": "reserved profile setting name",
 	},
+	"error.rest.cassette.mode": {
+		"en": "invalid REST cassette mode",
+	},
+	"error.rest.page.link": {
+		"en": "next page link is not on the server",
+	},
+	"error.rest.recording": {
+		"en": "no recorded response for request",
+	},
 	"error.row.number": {
 		"en": "invalid row number",
 	},
@@ -432,6 +492,39 @@ var messages = map[string]map[string]string{
 	"label.Active": {
 		"en": "Active",
 	},
+	"label.Changed": {
+		"en": "Changed",
+	},
+	"label.Expires": {
+		"en": "Expires",
+	},
+	"label.Message": {
+		"en": "Message",
+	},
+	"label.Modified": {
+		"en": "Modified",
+	},
+	"label.Remaining": {
+		"en": "Remaining",
+	},
+	"label.Replaced": {
+		"en": "Replaced",
+	},
+	"label.Sequence": {
+		"en": "Sequence",
+	},
+	"label.Server": {
+		"en": "Server",
+	},
+	"label.Session": {
+		"en": "Session",
+	},
+	"label.Time": {
+		"en": "Time",
+	},
+	"label.Version": {
+		"en": "Version",
+	},
 	"label.active.loggers": {
 		"en": "Active loggers: ",
 	},
@@ -456,6 +549,42 @@ var messages = map[string]map[string]string{
 	"label.Field": {
 		"en": "Field",
 	},
+	"label.apikey.prompt": {
+		"en": "API key: ",
+	},
+	"label.error.cause": {
+		"en": "caused by",
+	},
+	"label.error.code": {
+		"en": "code",
+	},
+	"label.error.stack": {
+		"en": "stack",
+	},
+	"label.exit.auth": {
+		"en": "Not logged on, or the credentials were rejected",
+	},
+	"label.exit.codes": {
+		"en": "Exit codes",
+	},
+	"label.exit.general": {
+		"en": "The command failed",
+	},
+	"label.exit.network": {
+		"en": "The server could not be reached or is unavailable",
+	},
+	"label.exit.not.found": {
+		"en": "The requested item does not exist",
+	},
+	"label.exit.success": {
+		"en": "The command succeeded",
+	},
+	"label.exit.usage": {
+		"en": "Invalid command line options or parameters",
+	},
+	"label.expired": {
+		"en": "expired",
+	},
 	"label.had.default.verb": {
 		"en": "(*) indicates the default subcommand if none given",
 	},
@@ -552,9 +681,18 @@ var messages = map[string]map[string]string{
 	"label.version": {
 		"en": "version",
 	},
+	"msg.cache.cleared": {
+		"en": "Removed {{count|plural one{# cached response} other{# cached responses}}}",
+	},
 	"msg.config.deleted": {
 		"en": "Configuration {{name}} deleted",
 	},
+	"msg.config.no.history": {
+		"en": "Configuration {{name}} has no history",
+	},
+	"msg.config.rolled.back": {
+		"en": "Configuration {{name}} restored to version {{version}}",
+	},
 	"msg.config.written": {
 		"en": "Configuration key {{key}} written",
 	},
@@ -568,7 +706,7 @@ var messages = map[string]map[string]string{
 		"en": "Debugger error, {{err}}",
 	},
 	"msg.debug.load.count": {
-		"en": "Loaded {{count}} breakpoints",
+		"en": "Loaded {{count|plural one{# breakpoint} other{# breakpoints}}}",
 	},
 	"msg.debug.no.breakpoints": {
 		"en": "No breakpoints defined",
@@ -580,7 +718,7 @@ var messages = map[string]map[string]string{
 		"en": "Return from entrypoint",
 	},
 	"msg.debug.save.count": {
-		"en": "Saving {{count}} breakpoints",
+		"en": "Saving {{count|plural one{# breakpoint} other{# breakpoints}}}",
 	},
 	"msg.debug.scope": {
 		"en": "Symbol table scope:",
@@ -594,11 +732,23 @@ var messages = map[string]map[string]string{
 	"msg.logged.in": {
 		"en": "Successfully logged in as {{user}}, valid until {{expires}}",
 	},
+	"msg.logged.in.no.expiration": {
+		"en": "Successfully logged in as {{user}}",
+	},
+	"msg.logged.out": {
+		"en": "Logged off from {{server}}",
+	},
+	"msg.logon.device": {
+		"en": "To log on, visit {{url}} and enter the code {{code}}",
+	},
+	"msg.logon.none": {
+		"en": "Not logged on to any server",
+	},
 	"msg.server.cache": {
 		"en": "Server Cache, hostname {{host}}, ID {{id}}",
 	},
 	"msg.server.cache.assets": {
-		"en": "There are {{count}} HTML assets in cache, for a total size of {{size}} bytes.",
+		"en": "There are {{count|number}} HTML assets in cache, for a total size of {{size|plural one{# byte} other{# bytes}}}.",
 	},
 	"msg.server.cache.emptied": {
 		"en": "Server cache emptied",
@@ -607,16 +757,16 @@ var messages = map[string]map[string]string{
 		"en": "There are no HTML assets cached.",
 	},
 	"msg.server.cache.no.services": {
-		"en": "There are no service items in cache. The maximum cache size is {{limit}} items.",
+		"en": "There are no service items in cache. The maximum cache size is {{limit|plural one{# item} other{# items}}}.",
 	},
 	"msg.server.cache.one.asset": {
-		"en": "There is 1 HTML asset in cache, for a total size of {{size}} bytes.",
+		"en": "There is 1 HTML asset in cache, for a total size of {{size|plural one{# byte} other{# bytes}}}.",
 	},
 	"msg.server.cache.one.service": {
-		"en": "There is 1 service item in cache. The maximum cache size is {{limit}} items.",
+		"en": "There is 1 service item in cache. The maximum cache size is {{limit|plural one{# item} other{# items}}}.",
 	},
 	"msg.server.cache.services": {
-		"en": "There are {{count}} service items in cache. The maximum cache size is {{limit}} items.",
+		"en": "There are {{count|number}} service items in cache. The maximum cache size is {{limit|plural one{# item} other{# items}}}.",
 	},
 	"msg.server.cache.updated": {
 		"en": "Server cache size updated",
@@ -628,10 +778,10 @@ var messages = map[string]map[string]string{
 		"en": "Server does not retain previous log files",
 	},
 	"msg.server.logs.purged": {
-		"en": "Purged {{count}} old log files",
+		"en": "Purged {{count|plural one{# old log file} other{# old log files}}}",
 	},
 	"msg.server.logs.retains": {
-		"en": "Server also retains last {{count}} previous log files",
+		"en": "Server also retains {{count|plural one{the last previous log file} other{the last # previous log files}}}",
 	},
 	"msg.server.logs.status": {
 		"en": "Logging status, hostname {{host}}, ID {{id}}",
@@ -649,10 +799,10 @@ var messages = map[string]map[string]string{
 		"en": "Server (pid {{pid}}) stopped",
 	},
 	"msg.table.created": {
-		"en": "Created table {{name}} with {{count}} columns",
+		"en": "Created table {{name}} with {{count|plural one{# column} other{# columns}}}",
 	},
 	"msg.table.delete.count": {
-		"en": "Deleted {{count}} tables",
+		"en": "Deleted {{count|plural one{# table} other{# tables}}}",
 	},
 	"msg.table.deleted": {
 		"en": "Table {{name}} deleted",
@@ -661,13 +811,13 @@ var messages = map[string]map[string]string{
 		"en": "No rows deleted",
 	},
 	"msg.table.deleted.rows": {
-		"en": "{{count}} rows deleted",
+		"en": "{{count|plural one{# row} other{# rows}}} deleted",
 	},
 	"msg.table.empty.rowset": {
 		"en": "No rows in result",
 	},
 	"msg.table.insert.count": {
-		"en": "Added {{count}} rows to table {{name}}",
+		"en": "Added {{count|plural one{# row} other{# rows}}} to table {{name}}",
 	},
 	"msg.table.no.insert": {
 		"en": "Nothing to insert into table",
@@ -679,10 +829,10 @@ var messages = map[string]map[string]string{
 		"en": "1 row modified",
 	},
 	"msg.table.sql.rows": {
-		"en": "{{count}} rows modified",
+		"en": "{{count|plural one{# row} other{# rows}}} modified",
 	},
 	"msg.table.update.count": {
-		"en": "Updated {{count}} rows in table {{name}}",
+		"en": "Updated {{count|plural one{# row} other{# rows}}} in table {{name}}",
 	},
 	"msg.table.user.permissions": {
 		"en": "User {{user}} permissions for {{schema}}.{{table}} {{verb}}: {{perms}}",
@@ -702,15 +852,27 @@ var messages = map[string]map[string]string{
 	"opt.filter": {
 		"en": "List of optional filter clauses",
 	},
+	"opt.global.debug": {
+		"en": "If specified, report errors with their causes and the Go call stack where they occurred",
+	},
 	"opt.global.format": {
 		"en": "Specify text, json or indented output format",
 	},
+	"opt.global.language": {
+		"en": "Language for messages, such as fr or pt-BR",
+	},
 	"opt.global.log": {
-		"en": "Loggers to enable",
+		"en": "Loggers to enable, optionally with a level such as server:debug",
 	},
 	"opt.global.log.file": {
 		"en": "Name of file where log messages are written",
 	},
+	"opt.global.log.format": {
+		"en": "Specify text or json format for log messages",
+	},
+	"opt.global.log.sink": {
+		"en": "Additional log destinations: stdout, stderr, file=path, syslog[=socket], memory[=size], each optionally followed by :logger,... to limit the loggers",
+	},
 	"opt.global.profile": {
 		"en": "Name of profile to use",
 	},
@@ -732,9 +894,45 @@ var messages = map[string]map[string]string{
 	"opt.local": {
 		"en": "Show local server status info",
 	},
+	"opt.log.class": {
+		"en": "Show only messages from these loggers",
+	},
+	"opt.log.file": {
+		"en": "Log file to read; the default is the current log file",
+	},
+	"opt.log.first": {
+		"en": "Show messages with this sequence number or higher",
+	},
+	"opt.log.last": {
+		"en": "Show messages with this sequence number or lower",
+	},
+	"opt.log.limit": {
+		"en": "Show at most this many of the most recent messages",
+	},
+	"opt.log.match": {
+		"en": "Show only messages that match this regular expression",
+	},
+	"opt.log.session": {
+		"en": "Show only messages for this session",
+	},
+	"opt.log.since": {
+		"en": "Show messages at or after this time, or this long ago, such as 2h",
+	},
+	"opt.log.until": {
+		"en": "Show messages at or before this time, or this long ago",
+	},
+	"opt.logon.method": {
+		"en": "Authentication method to use for logon",
+	},
 	"opt.logon.server": {
 		"en": "URL of server to authenticate with",
 	},
+	"opt.logout.all": {
+		"en": "Log off from all servers",
+	},
+	"opt.logout.revoke": {
+		"en": "Ask the server to revoke the token",
+	},
 	"opt.password": {
 		"en": "Password for logon",
 	},
@@ -927,4 +1125,40 @@ var messages = map[string]map[string]string{
 	"parm.name": {
 		"en": "name",
 	},
+	"parm.version": {
+		"en": "version",
+	},
+	"unit.days": {
+		"en": "{{count|plural one{# day} other{# days}}}",
+		"de": "{{count|plural one{# Tag} other{# Tage}}}",
+		"es": "{{count|plural one{# día} other{# días}}}",
+		"fr": "{{count|plural one{# jour} other{# jours}}}",
+	},
+	"unit.hours": {
+		"en": "{{count|plural one{# hour} other{# hours}}}",
+		"de": "{{count|plural one{# Stunde} other{# Stunden}}}",
+		"es": "{{count|plural one{# hora} other{# horas}}}",
+		"fr": "{{count|plural one{# heure} other{# heures}}}",
+	},
+	"unit.milliseconds": {
+		"en": "{{count|plural one{# millisecond} other{# milliseconds}}}",
+		"de": "{{count|plural one{# Millisekunde} other{# Millisekunden}}}",
+		"es": "{{count|plural one{# milisegundo} other{# milisegundos}}}",
+		"fr": "{{count|plural one{# milliseconde} other{# millisecondes}}}",
+	},
+	"unit.minutes": {
+		"en": "{{count|plural one{# minute} other{# minutes}}}",
+		"de": "{{count|plural one{# Minute} other{# Minuten}}}",
+		"es": "{{count|plural one{# minuto} other{# minutos}}}",
+		"fr": "{{count|plural one{# minute} other{# minutes}}}",
+	},
+	"unit.seconds": {
+		"en": "{{count|plural one{# second} other{# seconds}}}",
+		"de": "{{count|plural one{# Sekunde} other{# Sekunden}}}",
+		"es": "{{count|plural one{# segundo} other{# segundos}}}",
+		"fr": "{{count|plural one{# seconde} other{# secondes}}}",
+	},
+	"unit.separator": {
+		"en": ", ",
+	},
 }
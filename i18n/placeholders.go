@@ -0,0 +1,205 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// substitute replaces the placeholders in a message with values from the map.
+// A placeholder is the name of a value in double braces, optionally followed
+// by a bar and a format:
+//
+//	{{name}}               the value, formatted with %v
+//	{{count|number}}       a number, using the digit grouping of the language
+//	{{ratio|number:2}}     a number with exactly two decimal places
+//	{{when|date}}          the date of a time.Time value
+//	{{when|time}}          the time of day of a time.Time value
+//	{{when|datetime}}      the date and time of a time.Time value
+//	{{elapsed|duration}}   a time.Duration, such as "2 hours, 5 minutes"
+//	{{count|plural one{# row} other{# rows}}}
+//
+// A plural placeholder selects the form for the CLDR plural category of the
+// value in the current language, such as one, few, many, or other. A form
+// named "=n" is used for the exact value n, in preference to the category.
+// In the selected form, "#" is replaced by the formatted number, and any
+// placeholders are substituted as well.
+//
+// A placeholder whose name is not in the map is left unchanged.
+func substitute(text string, values map[string]interface{}) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+
+	b := strings.Builder{}
+
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			b.WriteString(text)
+
+			break
+		}
+
+		b.WriteString(text[:start])
+		text = text[start+2:]
+
+		name, spec, rest, ok := parsePlaceholder(text)
+		if !ok {
+			b.WriteString("{{")
+
+			continue
+		}
+
+		value, found := values[name]
+		if !found {
+			b.WriteString("{{" + text[:len(text)-len(rest)])
+		} else {
+			b.WriteString(formatValue(value, spec, values))
+		}
+
+		text = rest
+	}
+
+	return b.String()
+}
+
+// parsePlaceholder parses the text following the opening braces of a
+// placeholder. It returns the name and format, and the text that follows
+// the closing braces. The result is false if the text is not a placeholder.
+func parsePlaceholder(text string) (string, string, string, bool) {
+	end := strings.IndexFunc(text, func(ch rune) bool {
+		return !unicode.IsLetter(ch) && !unicode.IsDigit(ch) && ch != '_' && ch != '.'
+	})
+	if end <= 0 {
+		return "", "", "", false
+	}
+
+	name := text[:end]
+	text = text[end:]
+
+	if strings.HasPrefix(text, "}}") {
+		return name, "", text[2:], true
+	}
+
+	if !strings.HasPrefix(text, "|") {
+		return "", "", "", false
+	}
+
+	// Find the closing braces, skipping over any braces nested in the format.
+	depth := 0
+
+	for n := 1; n < len(text); n++ {
+		switch {
+		case depth == 0 && strings.HasPrefix(text[n:], "}}"):
+			return name, strings.TrimSpace(text[1:n]), text[n+2:], true
+
+		case text[n] == '{':
+			depth++
+
+		case text[n] == '}' && depth > 0:
+			depth--
+		}
+	}
+
+	return "", "", "", false
+}
+
+// formatValue formats a placeholder value using the format of the placeholder.
+func formatValue(value interface{}, spec string, values map[string]interface{}) string {
+	if strings.HasPrefix(spec, "plural") {
+		return formatPlural(value, strings.TrimSpace(strings.TrimPrefix(spec, "plural")), values)
+	}
+
+	kind, argument, _ := strings.Cut(spec, ":")
+	kind = strings.TrimSpace(kind)
+
+	switch kind {
+	case "number":
+		if decimals, err := strconv.Atoi(strings.TrimSpace(argument)); err == nil {
+			if f, ok := floatValue(value); ok {
+				return FormatFloat(f, decimals)
+			}
+		}
+
+		return FormatNumber(value)
+
+	case "date", "time", "datetime":
+		t, ok := value.(time.Time)
+		if !ok {
+			break
+		}
+
+		switch kind {
+		case "date":
+			return FormatDate(t)
+		case "time":
+			return FormatTime(t)
+		default:
+			return FormatDateTime(t)
+		}
+
+	case "duration":
+		switch actual := value.(type) {
+		case time.Duration:
+			return FormatDuration(actual)
+
+		default:
+			if seconds, ok := floatValue(value); ok {
+				return FormatDuration(time.Duration(seconds * float64(time.Second)))
+			}
+		}
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// formatPlural selects the plural form for a value. The forms are a list of
+// selectors, each followed by the text of the form in braces.
+func formatPlural(value interface{}, forms string, values map[string]interface{}) string {
+	choices := map[string]string{}
+
+	for forms != "" {
+		open := strings.Index(forms, "{")
+		if open < 0 {
+			break
+		}
+
+		selector := strings.TrimSpace(forms[:open])
+		depth := 0
+		end := -1
+
+		for n := open; n < len(forms) && end < 0; n++ {
+			switch forms[n] {
+			case '{':
+				depth++
+
+			case '}':
+				depth--
+				if depth == 0 {
+					end = n
+				}
+			}
+		}
+
+		if end < 0 {
+			break
+		}
+
+		choices[selector] = forms[open+1 : end]
+		forms = strings.TrimSpace(forms[end+1:])
+	}
+
+	number := FormatNumber(value)
+
+	text, found := choices["="+fmt.Sprintf("%v", value)]
+	if !found {
+		if text, found = choices[PluralCategory(value)]; !found {
+			text = choices[PluralOther]
+		}
+	}
+
+	return substitute(strings.ReplaceAll(text, "#", number), values)
+}
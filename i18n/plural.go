@@ -0,0 +1,169 @@
+package i18n
+
+import (
+	"math"
+)
+
+// The plural categories, as defined by the Unicode CLDR. Every language uses
+// "other"; each language uses only some of the rest.
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+// pluralRule returns the plural category of a number. The number is given as
+// its absolute integer part, and a flag that is true if it has no fractional
+// part.
+type pluralRule func(i int64, integer bool) string
+
+// The plural rules for each language, and for regions whose rule differs from
+// that of their language. A language that is not in this table uses the "en"
+// rule.
+var pluralRules = map[string]pluralRule{
+	"ar":    arabicPlural,
+	"cs":    czechPlural,
+	"en":    englishPlural,
+	"fr":    frenchPlural,
+	"ja":    noPlural,
+	"ko":    noPlural,
+	"pl":    polishPlural,
+	"pt":    frenchPlural,
+	"pt-PT": englishPlural,
+	"ru":    russianPlural,
+	"sk":    czechPlural,
+	"uk":    russianPlural,
+	"zh":    noPlural,
+}
+
+// PluralCategory returns the CLDR plural category of a number in the current
+// language, such as "one" for 1 and "other" for 2 in English, or "few" for 3
+// in Russian.
+func PluralCategory(value interface{}) string {
+	f, ok := floatValue(value)
+	if !ok {
+		return PluralOther
+	}
+
+	f = math.Abs(f)
+
+	for _, language := range languages() {
+		if rule, found := pluralRules[language]; found {
+			return rule(int64(f), f == math.Trunc(f))
+		}
+	}
+
+	return englishPlural(int64(f), f == math.Trunc(f))
+}
+
+// englishPlural is the rule for most European languages: one is singular and
+// everything else, including decimals, is plural.
+func englishPlural(i int64, integer bool) string {
+	if i == 1 && integer {
+		return PluralOne
+	}
+
+	return PluralOther
+}
+
+// frenchPlural treats zero and one, with or without decimals, as singular.
+func frenchPlural(i int64, integer bool) string {
+	if i == 0 || i == 1 {
+		return PluralOne
+	}
+
+	return PluralOther
+}
+
+// noPlural is the rule for languages that do not inflect for number.
+func noPlural(i int64, integer bool) string {
+	return PluralOther
+}
+
+// russianPlural is the rule for Russian and Ukrainian, which choose the form
+// from the last one or two digits.
+func russianPlural(i int64, integer bool) string {
+	if !integer {
+		return PluralOther
+	}
+
+	mod10, mod100 := i%10, i%100
+
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return PluralOne
+
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	}
+
+	return PluralMany
+}
+
+// polishPlural is like the Russian rule, except that only one itself is
+// singular.
+func polishPlural(i int64, integer bool) string {
+	if !integer {
+		return PluralOther
+	}
+
+	mod10, mod100 := i%10, i%100
+
+	switch {
+	case i == 1:
+		return PluralOne
+
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	}
+
+	return PluralMany
+}
+
+// czechPlural is the rule for Czech and Slovak, which use a form for two to
+// four and another for decimals.
+func czechPlural(i int64, integer bool) string {
+	switch {
+	case !integer:
+		return PluralMany
+
+	case i == 1:
+		return PluralOne
+
+	case i >= 2 && i <= 4:
+		return PluralFew
+	}
+
+	return PluralOther
+}
+
+// arabicPlural uses all six categories.
+func arabicPlural(i int64, integer bool) string {
+	if !integer {
+		return PluralOther
+	}
+
+	mod100 := i % 100
+
+	switch {
+	case i == 0:
+		return PluralZero
+
+	case i == 1:
+		return PluralOne
+
+	case i == 2:
+		return PluralTwo
+
+	case mod100 >= 3 && mod100 <= 10:
+		return PluralFew
+
+	case mod100 >= 11:
+		return PluralMany
+	}
+
+	return PluralOther
+}
@@ -3,14 +3,14 @@
 package i18n
 
 import (
-	"fmt"
-	"os"
 	"strings"
 )
 
-// Language is a string that identifies the current language, such as
-// "en" for English or "fr" for French. This is used as a key in the
-// internal localization dictionaries.
+// Language is a BCP 47 language tag that identifies the current language, such
+// as "en" for English, "fr" for French, or "pt-BR" for Brazilian Portuguese.
+// This is used as a key in the internal localization dictionaries. If it is
+// empty, it is set from the environment the first time it is needed; use
+// SetLanguage to set it from a tag that may not be in canonical form.
 var Language string
 
 // Register adds additional localizations to the localization database
@@ -25,44 +25,35 @@ func Register(localizations map[string]map[string]string) {
 				messages[key] = map[string]string{}
 			}
 
-			messages[key][language] = text
+			messages[key][canonicalLanguage(language)] = text
 		}
 	}
 }
 
 // T converts a key into the localized string, based on the current language
-// definition. If there is no localization in the given language, then "en"
-// is also searched to see if it has a value. Finally, if no localization exists,
-// the string is returned as-is.
+// definition. If there is no localization in the given language, the languages
+// it falls back to are searched, ending with "en"; see LanguageFallbacks.
+// Finally, if no localization exists, the string is returned as-is.
 //
-// The second optional parameter is a map of string substitutions that should
-// be done within the message text before returning the formatted value.
+// The second optional parameter is a map of values that are substituted for
+// the placeholders in the message text, such as {{name}}. A placeholder can
+// also format its value as a number, date, time, or duration in the current
+// language, or choose a plural form; see substitute for the syntax.
 func T(key string, valueMap ...map[string]interface{}) string {
-	// If we haven't yet figure out what language, do that now.
-	if Language == "" {
-		Language = os.Getenv("APP_LANG")
-		if Language == "" {
-			Language = os.Getenv("LANG")
-		}
+	// Find the message using the current language, or the languages it
+	// falls back to, such as "pt" and then "en" for "pt-BR".
+	text := key
 
-		if len(Language) > 2 {
-			Language = Language[0:2]
-		}
-	}
+	for _, language := range languages() {
+		if localized, found := messages[key][language]; found {
+			text = localized
 
-	// Find the message using the current language
-	text, ok := messages[key][Language]
-	if !ok {
-		text, ok = messages[key]["en"]
-		if !ok {
-			text = key
+			break
 		}
 	}
 
 	if len(valueMap) > 0 {
-		for tag, value := range valueMap[0] {
-			text = strings.ReplaceAll(text, "{{"+tag+"}}", fmt.Sprintf("%v", value))
-		}
+		text = substitute(text, valueMap[0])
 	}
 
 	return text
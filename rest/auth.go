@@ -0,0 +1,467 @@
+package rest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/i18n"
+	"gopkg.in/resty.v1"
+)
+
+// These are the names of the built-in authentication methods. The method
+// used for logon is selected using the profile setting
+// defs.LogonAuthenticationSetting, and the method is stored with each
+// credential so later requests to that server use the same method.
+const (
+	// TokenAuthentication posts a username and password to the Ego logon
+	// endpoint, and uses the resulting bearer token. This is the default.
+	TokenAuthentication = "token"
+
+	// BasicAuthentication sends the username and password with each
+	// request using HTTP Basic authentication. Only the username is
+	// stored in the profile.
+	BasicAuthentication = "basic"
+
+	// APIKeyAuthentication sends an API key in a request header with each
+	// request.
+	APIKeyAuthentication = "apikey"
+
+	// CertificateAuthentication uses a client certificate (mutual TLS).
+	CertificateAuthentication = "certificate"
+
+	// OAuth2Authentication uses the OAuth2 device authorization flow to get
+	// a bearer token.
+	OAuth2Authentication = "oauth2"
+)
+
+// DefaultAPIKeyHeader is the name of the request header used to send an
+// API key, if not overridden by the defs.LogonAPIKeyHeaderSetting.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// Authenticator is the interface implemented by an authentication method.
+// Additional methods can be added using RegisterAuthenticator().
+type Authenticator interface {
+	// Logon obtains a credential for the given server. The user and secret
+	// are the values from the command line, if any. The authenticator must
+	// prompt for any missing values it requires.
+	Logon(server, user, secret string) (Credential, error)
+
+	// Authenticate adds the authentication described by the credential to
+	// a request that is about to be sent to the credential's server.
+	Authenticate(c Credential, client *resty.Client, request *resty.Request) error
+}
+
+// Refresher is implemented by an Authenticator whose credentials expire and
+// can be refreshed without prompting the user again.
+type Refresher interface {
+	// Refresh returns a replacement for a credential that is about to
+	// expire.
+	Refresh(c Credential) (Credential, error)
+}
+
+var authenticators = map[string]Authenticator{
+	TokenAuthentication:       &tokenAuthenticator{},
+	BasicAuthentication:       &basicAuthenticator{},
+	APIKeyAuthentication:      &apiKeyAuthenticator{},
+	CertificateAuthentication: &certificateAuthenticator{},
+	OAuth2Authentication:      &oauth2Authenticator{},
+}
+
+var authenticatorsMutex sync.Mutex
+
+// RegisterAuthenticator adds an authentication method, or replaces one of
+// the built-in methods. The name is the value used to select the method in
+// the profile.
+func RegisterAuthenticator(name string, a Authenticator) {
+	authenticatorsMutex.Lock()
+	defer authenticatorsMutex.Unlock()
+
+	authenticators[strings.ToLower(name)] = a
+}
+
+// AuthenticationMethods returns a sorted list of the names of the available
+// authentication methods.
+func AuthenticationMethods() []string {
+	authenticatorsMutex.Lock()
+	defer authenticatorsMutex.Unlock()
+
+	result := make([]string, 0, len(authenticators))
+	for name := range authenticators {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// AuthenticationMethod returns the name of the authentication method selected
+// in the current profile. If none is selected, the token method is used.
+func AuthenticationMethod() string {
+	if method := strings.ToLower(strings.TrimSpace(settings.Get(defs.LogonAuthenticationSetting))); method != "" {
+		return method
+	}
+
+	return TokenAuthentication
+}
+
+// GetAuthenticator returns the authenticator for the named method. If the name
+// is empty, the method selected in the current profile is used.
+func GetAuthenticator(name string) (Authenticator, error) {
+	if name == "" {
+		name = AuthenticationMethod()
+	}
+
+	authenticatorsMutex.Lock()
+	defer authenticatorsMutex.Unlock()
+
+	if a, found := authenticators[strings.ToLower(name)]; found {
+		return a, nil
+	}
+
+	return nil, errors.ErrInvalidAuthenticationType.Context(name)
+}
+
+// authenticate adds the stored authentication for the server to a request.
+// If the credential is about to expire it is refreshed first. If there is no
+// stored credential, but the profile selects client certificates, the
+// certificate is still added.
+func authenticate(server string, client *resty.Client, request *resty.Request, checkExpiration bool) error {
+	c, found, err := activeCredential(server, checkExpiration)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		if AuthenticationMethod() != CertificateAuthentication {
+			return nil
+		}
+
+		c = Credential{Server: normalizeServer(server), Method: CertificateAuthentication}
+	}
+
+	a, err := GetAuthenticator(c.method())
+	if err != nil {
+		return err
+	}
+
+	return a.Authenticate(c, client, request)
+}
+
+// tokenAuthenticator implements the Ego logon, which exchanges a username
+// and password for a bearer token.
+type tokenAuthenticator struct{}
+
+func (a *tokenAuthenticator) Logon(server, user, secret string) (Credential, error) {
+	user, secret, err := promptCredentials(server, user, secret)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	url := strings.TrimSuffix(server, "/") + defs.ServicesLogonPath
+
+	// Create a new client, and generate a request. The request is made using
+	// the logon agent info.
+	restClient, err := newRestClient(nil)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	restClient.SetDisableWarn(true)
+
+	req := restClient.NewRequest()
+	req.Body = defs.Credentials{Username: user, Password: secret}
+
+	if ui.IsActive(ui.RestLogger) {
+		// Use a fake password payload for the REST logging so we don't expose the password
+		b, _ := json.MarshalIndent(defs.Credentials{Username: user, Password: "********"}, "", "  ")
+		ui.Log(ui.RestLogger, "REST Request:\n%s", string(b))
+	}
+
+	req.Header.Set("Accept", defs.JSONMediaType)
+	AddAgent(req, defs.LogonAgent)
+
+	r, err := req.Post(url)
+	if err != nil {
+		ui.Log(ui.RestLogger, "REST POST %s; failed %v", url, err)
+
+		return Credential{}, errors.NewError(err)
+	}
+
+	ui.Log(ui.RestLogger, "REST POST %s; status %d", url, r.StatusCode())
+
+	switch r.StatusCode() {
+	case http.StatusOK:
+		payload := defs.LogonResponse{}
+
+		if err := json.Unmarshal(r.Body(), &payload); err != nil {
+			return Credential{}, errors.NewError(err).In("logon")
+		}
+
+		if ui.IsActive(ui.RestLogger) {
+			b, _ := json.MarshalIndent(payload, "", "  ")
+			ui.Log(ui.RestLogger, "REST Response:\n%s", string(b))
+		}
+
+		return Credential{
+			Server:     server,
+			User:       user,
+			Token:      payload.Token,
+			Expiration: payload.Expiration,
+			Method:     TokenAuthentication,
+		}, nil
+
+	case http.StatusUnauthorized:
+		return Credential{}, errors.ErrNoCredentials
+
+	case http.StatusForbidden:
+		return Credential{}, errors.ErrInvalidCredentials
+
+	case http.StatusNotFound:
+		return Credential{}, errors.ErrLogonEndpoint
+
+	default:
+		return Credential{}, errors.ErrHTTP.Context(r.StatusCode())
+	}
+}
+
+func (a *tokenAuthenticator) Authenticate(c Credential, client *resty.Client, request *resty.Request) error {
+	if c.Token != "" {
+		request.SetAuthToken(c.Token)
+		ui.Log(ui.RestLogger, "Authorization set using bearer token")
+	}
+
+	return nil
+}
+
+func (a *tokenAuthenticator) Refresh(c Credential) (Credential, error) {
+	return refreshToken(c)
+}
+
+// basicAuthenticator sends the username and password with every request.
+// The password is not stored in the profile, since it could be recovered
+// from it; only the username is. The password is kept in memory for the
+// rest of the process. A later process uses the password in the APP_PASSWORD
+// environment variable. The user is not prompted for it when a request is
+// sent, since the request may be sent by a script. A credential stored by an
+// earlier version, whose token holds the encoded username and password, is
+// still used until the user logs on again.
+type basicAuthenticator struct{}
+
+// The passwords given for basic authentication, by server and user.
+var basicPasswords = map[string]string{}
+
+var basicPasswordsMutex sync.Mutex
+
+func (a *basicAuthenticator) Logon(server, user, secret string) (Credential, error) {
+	user, secret, err := promptCredentials(server, user, secret)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	setBasicPassword(server, user, secret)
+
+	return Credential{
+		Server: server,
+		User:   user,
+		Method: BasicAuthentication,
+	}, nil
+}
+
+func (a *basicAuthenticator) Authenticate(c Credential, client *resty.Client, request *resty.Request) error {
+	token := c.Token
+	if token == "" {
+		if c.User == "" {
+			return errors.ErrNoCredentials.Context(c.Server)
+		}
+
+		password := basicPassword(c.Server, c.User)
+		if password == "" {
+			password = os.Getenv(defs.DefaultPassword)
+		}
+
+		if password == "" {
+			return errors.ErrNoCredentials.Context(c.Server)
+		}
+
+		token = base64.StdEncoding.EncodeToString([]byte(c.User + ":" + password))
+	}
+
+	request.Header.Set("Authorization", "Basic "+token)
+	ui.Log(ui.RestLogger, "Authorization set using basic authentication for user %s", c.User)
+
+	return nil
+}
+
+// basicPassword returns the password given for a user of a server during
+// this process, or an empty string if there is none.
+func basicPassword(server, user string) string {
+	basicPasswordsMutex.Lock()
+	defer basicPasswordsMutex.Unlock()
+
+	return basicPasswords[normalizeServer(server)+" "+user]
+}
+
+// setBasicPassword remembers the password for a user of a server for the
+// rest of this process.
+func setBasicPassword(server, user, password string) {
+	basicPasswordsMutex.Lock()
+	defer basicPasswordsMutex.Unlock()
+
+	basicPasswords[normalizeServer(server)+" "+user] = password
+}
+
+// apiKeyAuthenticator sends an API key in a request header with every
+// request. The credential token holds the API key.
+type apiKeyAuthenticator struct{}
+
+func (a *apiKeyAuthenticator) Logon(server, user, secret string) (Credential, error) {
+	for n := 0; secret == "" && n < maxPromptAttempts; n++ {
+		secret = ui.PromptPassword(i18n.L("apikey.prompt"))
+	}
+
+	if secret == "" {
+		return Credential{}, errors.ErrNoCredentials.Context(server)
+	}
+
+	return Credential{
+		Server: server,
+		User:   user,
+		Token:  secret,
+		Method: APIKeyAuthentication,
+	}, nil
+}
+
+func (a *apiKeyAuthenticator) Authenticate(c Credential, client *resty.Client, request *resty.Request) error {
+	if c.Token == "" {
+		return errors.ErrNoCredentials.Context(c.Server)
+	}
+
+	header := settings.Get(defs.LogonAPIKeyHeaderSetting)
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	request.Header.Set(header, c.Token)
+	ui.Log(ui.RestLogger, "Authorization set using API key header %s", header)
+
+	return nil
+}
+
+// certificateAuthenticator presents a client certificate when the TLS
+// connection is made. The certificate and key files are named in the
+// profile.
+type certificateAuthenticator struct{}
+
+// Guards the TLS configuration of transports while a client certificate is
+// added to it, since requests sent at the same time are all authenticated.
+var certificateMutex sync.Mutex
+
+func (a *certificateAuthenticator) Logon(server, user, secret string) (Credential, error) {
+	cert, err := clientCertificate()
+	if err != nil {
+		return Credential{}, err
+	}
+
+	// Use the subject of the certificate as the user name.
+	if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+		user = leaf.Subject.CommonName
+	}
+
+	return Credential{
+		Server: server,
+		User:   user,
+		Method: CertificateAuthentication,
+	}, nil
+}
+
+func (a *certificateAuthenticator) Authenticate(c Credential, client *resty.Client, request *resty.Request) error {
+	// The certificate is added to the TLS configuration of the transport
+	// that makes the connections, which may be wrapped by a cassette or a
+	// cache. A replayed request makes no connection, so needs none.
+	transport := baseTransport(client.GetClient().Transport)
+	if transport == nil {
+		return nil
+	}
+
+	certificateMutex.Lock()
+	defer certificateMutex.Unlock()
+
+	// The client is reused for later requests, so the certificate only needs
+	// to be added once.
+	if transport.TLSClientConfig != nil && len(transport.TLSClientConfig.Certificates) > 0 {
+		return nil
+	}
+
+	cert, err := clientCertificate()
+	if err != nil {
+		return err
+	}
+
+	// The configuration is copied, so a configuration given to the client
+	// by the caller is not changed.
+	config := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		config = transport.TLSClientConfig.Clone()
+	}
+
+	config.Certificates = append(config.Certificates, cert)
+	transport.TLSClientConfig = config
+
+	ui.Log(ui.RestLogger, "Authorization set using client certificate %s", settings.Get(defs.ClientCertificateFileSetting))
+
+	return nil
+}
+
+// clientCertificate reads the client certificate and key files named in the
+// profile.
+func clientCertificate() (tls.Certificate, error) {
+	certFile := settings.Get(defs.ClientCertificateFileSetting)
+	keyFile := settings.Get(defs.ClientKeyFileSetting)
+
+	if certFile == "" || keyFile == "" {
+		return tls.Certificate{}, errors.ErrNoCredentials.Context(defs.ClientCertificateFileSetting)
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return cert, errors.ErrCertificateParseError.Context(certFile)
+	}
+
+	return cert, nil
+}
+
+// The number of times the user is prompted for a value that is not given
+// before the logon fails. An empty value is read when there is no terminal,
+// so prompting again would never succeed.
+const maxPromptAttempts = 3
+
+// promptCredentials prompts for a username and password if either was not
+// supplied on the command line. An error is returned if either is still
+// empty after prompting.
+func promptCredentials(server, user, password string) (string, string, error) {
+	for n := 0; user == "" && n < maxPromptAttempts; n++ {
+		user = ui.Prompt(i18n.L("username.prompt"))
+	}
+
+	for n := 0; user != "" && password == "" && n < maxPromptAttempts; n++ {
+		password = ui.PromptPassword(i18n.L("password.prompt"))
+	}
+
+	if user == "" || password == "" {
+		return user, password, errors.ErrNoCredentials.Context(server)
+	}
+
+	return user, password, nil
+}
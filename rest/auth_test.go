@@ -0,0 +1,242 @@
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"gopkg.in/resty.v1"
+)
+
+func TestAuthenticators(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	tests := []struct {
+		name       string
+		method     string
+		user       string
+		secret     string
+		header     string
+		wantHeader string
+	}{
+		{
+			name:       "token",
+			method:     TokenAuthentication,
+			wantHeader: "secret-token",
+		},
+		{
+			name:       "basic",
+			method:     BasicAuthentication,
+			user:       "joe",
+			secret:     "pass",
+			header:     "Authorization",
+			wantHeader: "Basic am9lOnBhc3M=",
+		},
+		{
+			name:       "api key",
+			method:     APIKeyAuthentication,
+			secret:     "my-key",
+			header:     DefaultAPIKeyHeader,
+			wantHeader: "my-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := GetAuthenticator(tt.method)
+			if err != nil {
+				t.Fatalf("GetAuthenticator() unexpected error %v", err)
+			}
+
+			c := Credential{Server: "https://example.com", Token: "secret-token", Method: tt.method}
+
+			// Token logons require a server, so only the other methods are
+			// logged on here.
+			if tt.method != TokenAuthentication {
+				if c, err = a.Logon("https://example.com", tt.user, tt.secret); err != nil {
+					t.Fatalf("Logon() unexpected error %v", err)
+				}
+			}
+
+			client := resty.New()
+			request := client.NewRequest()
+
+			if err := a.Authenticate(c, client, request); err != nil {
+				t.Fatalf("Authenticate() unexpected error %v", err)
+			}
+
+			// Bearer tokens are added to the request when it is sent, so
+			// check the request token instead of a header.
+			got := request.Token
+			if tt.header != "" {
+				got = request.Header.Get(tt.header)
+			}
+
+			if got != tt.wantHeader {
+				t.Errorf("Authenticate() header %s = %q, want %q", tt.header, got, tt.wantHeader)
+			}
+		})
+	}
+
+	if _, err := GetAuthenticator("no-such-method"); err == nil {
+		t.Errorf("GetAuthenticator() of unknown method did not return an error")
+	}
+
+	settings.Set(defs.LogonAuthenticationSetting, "BASIC")
+
+	if got := AuthenticationMethod(); got != BasicAuthentication {
+		t.Errorf("AuthenticationMethod() = %s, want %s", got, BasicAuthentication)
+	}
+}
+
+func TestCertificateAuthenticatorWrappedTransport(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir)
+
+	settings.Set(defs.ClientCertificateFileSetting, certFile)
+	settings.Set(defs.ClientKeyFileSetting, keyFile)
+
+	// The settings outlive the store, so they must not be left for the
+	// TLS configuration of other tests.
+	defer func() {
+		_ = settings.Delete(defs.ClientCertificateFileSetting)
+		_ = settings.Delete(defs.ClientKeyFileSetting)
+	}()
+
+	base := &http.Transport{TLSClientConfig: &tls.Config{ServerName: "example.com"}}
+	shared := base.TLSClientConfig
+	client := resty.New().SetTransport(NewCache(filepath.Join(dir, "cache")).Transport(base))
+
+	a, _ := GetAuthenticator(CertificateAuthentication)
+	c := Credential{Server: "https://example.com", Method: CertificateAuthentication}
+
+	// Requests sent at the same time are authenticated at the same time.
+	var wg sync.WaitGroup
+
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := a.Authenticate(c, client, client.NewRequest()); err != nil {
+				t.Errorf("Authenticate() unexpected error %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got := len(base.TLSClientConfig.Certificates); got != 1 || base.TLSClientConfig.ServerName != "example.com" {
+		t.Errorf("Authenticate() set %d certificates on the wrapped transport, want 1", got)
+	}
+
+	if len(shared.Certificates) != 0 {
+		t.Errorf("Authenticate() changed the TLS configuration given to the client")
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate and its key to the
+// directory, and returns the names of the files.
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "joe"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+
+	return certFile, keyFile
+}
+
+func TestBasicCredentialNotStored(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	a, _ := GetAuthenticator(BasicAuthentication)
+
+	c, err := a.Logon("https://basic.example.com", "joe", "pass")
+	if err != nil {
+		t.Fatalf("Logon() unexpected error %v", err)
+	}
+
+	SetCredential(c)
+	defer DeleteCredential(c.Server)
+
+	if stored := settings.Get(defs.LogonCredentialsSetting); c.Token != "" || strings.Contains(stored, "pass") || strings.Contains(stored, "am9lOnBhc3M") {
+		t.Errorf("Logon() stored the password: %s", stored)
+	}
+
+	// The password given to Logon() is used for requests by this process.
+	stored, _ := GetCredential("https://basic.example.com")
+	client := resty.New()
+	request := client.NewRequest()
+
+	if err := a.Authenticate(stored, client, request); err != nil || request.Header.Get("Authorization") != "Basic am9lOnBhc3M=" {
+		t.Errorf("Authenticate() = %q, %v", request.Header.Get("Authorization"), err)
+	}
+
+	// A later process does not prompt for the password, but uses the one in
+	// the environment, if there is one.
+	later := Credential{Server: "https://basic.example.com", User: "ann", Method: BasicAuthentication}
+
+	t.Setenv(defs.DefaultPassword, "")
+
+	if err := a.Authenticate(later, client, client.NewRequest()); !errors.ErrNoCredentials.Is(err) {
+		t.Errorf("Authenticate() without a password = %v, want %v", err, errors.ErrNoCredentials)
+	}
+
+	t.Setenv(defs.DefaultPassword, "word")
+
+	request = client.NewRequest()
+	if err := a.Authenticate(later, client, request); err != nil || request.Header.Get("Authorization") != "Basic YW5uOndvcmQ=" {
+		t.Errorf("Authenticate() with %s = %q, %v", defs.DefaultPassword, request.Header.Get("Authorization"), err)
+	}
+}
@@ -0,0 +1,500 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+// CacheDirectory is the name of the directory, in the profile directory, that
+// holds the cached REST responses.
+const CacheDirectory = "cache"
+
+// DefaultMaxCacheEntrySize is the size of the largest response body that is
+// cached, unless the cache sets a different limit.
+const DefaultMaxCacheEntrySize = 10 * 1024 * 1024
+
+// Cache stores the responses to REST GET requests on disk. A cached response
+// is returned without contacting the server until it expires, as described by
+// its Cache-Control or Expires header. After that, the request is sent with
+// the If-None-Match or If-Modified-Since header, so the server can reply that
+// the cached response is still valid without sending it again.
+//
+// A response is only cached if it has an ETag, Last-Modified, or expiration
+// time, and does not have a Cache-Control header of "no-store". A successful
+// request using any other method removes the cached response for the URL.
+//
+// Responses larger than the maximum entry size, and responses to requests
+// made by the streaming functions such as Download(), are not cached.
+type Cache struct {
+	// The directory that holds the cached responses.
+	Directory string
+
+	// The size of the largest response body that is cached. If zero, the
+	// DefaultMaxCacheEntrySize is used.
+	MaxEntrySize int64
+
+	mutex sync.Mutex
+}
+
+// cacheEntry describes a cached response. The response body is stored in a
+// separate file, so it does not have to be held in memory.
+type cacheEntry struct {
+	URL     string      `json:"url"`
+	Variant string      `json:"variant"`
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header"`
+	Stored  time.Time   `json:"stored"`
+	Expires time.Time   `json:"expires,omitempty"`
+	NoCache bool        `json:"nocache,omitempty"`
+}
+
+// cacheTransport sends requests through a cache.
+type cacheTransport struct {
+	cache *Cache
+	next  http.RoundTripper
+}
+
+// cachingBody copies a response body to the cache as it is read. The cached
+// response is only saved once the whole body has been read.
+type cachingBody struct {
+	body  io.ReadCloser
+	file  *os.File
+	cache *Cache
+	key   string
+	entry cacheEntry
+	done  bool
+
+	// The number of bytes that can still be written to the file before
+	// the body is too large to cache.
+	remaining int64
+}
+
+// noCacheKey is the context key that marks a request whose response must not
+// be cached, such as a streamed download.
+type noCacheKey struct{}
+
+// NewCache creates a cache that stores responses in the given directory.
+func NewCache(directory string) *Cache {
+	return &Cache{Directory: directory}
+}
+
+// DefaultCacheDirectory returns the directory used for cached responses when
+// caching is enabled in the profile.
+func DefaultCacheDirectory() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.NewError(err)
+	}
+
+	return filepath.Join(home, settings.ProfileDirectory, CacheDirectory), nil
+}
+
+// ProfileCache returns the cache in the default cache directory if caching is
+// enabled in the profile, or nil if it is not.
+func ProfileCache() (*Cache, error) {
+	if !settings.GetBool(defs.RestCacheSetting) {
+		return nil, nil
+	}
+
+	directory, err := DefaultCacheDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCache(directory), nil
+}
+
+// ClearCache removes all the responses from the default cache directory, even
+// if caching is not enabled in the profile. The number of responses removed
+// is returned.
+func ClearCache() (int, error) {
+	directory, err := DefaultCacheDirectory()
+	if err != nil {
+		return 0, err
+	}
+
+	return NewCache(directory).Clear()
+}
+
+// Transport returns a transport that uses the cache for requests sent using
+// the next transport.
+func (c *Cache) Transport(next http.RoundTripper) http.RoundTripper {
+	return &cacheTransport{cache: c, next: next}
+}
+
+// maxEntrySize returns the size of the largest response body that is cached.
+func (c *Cache) maxEntrySize() int64 {
+	if c.MaxEntrySize > 0 {
+		return c.MaxEntrySize
+	}
+
+	return DefaultMaxCacheEntrySize
+}
+
+// unwrap returns the transport that sends the requests that are not cached.
+func (t *cacheTransport) unwrap() http.RoundTripper {
+	return t.next
+}
+
+// Clear removes all the cached responses, and returns the number removed.
+func (c *Cache) Clear() (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(c.Directory, "*.json"))
+	if err != nil {
+		return 0, errors.NewError(err)
+	}
+
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			return 0, errors.NewError(err)
+		}
+
+		_ = os.Remove(strings.TrimSuffix(file, ".json") + ".body")
+	}
+
+	ui.Log(ui.RestLogger, "Removed %d cached responses from %s", len(files), c.Directory)
+
+	return len(files), nil
+}
+
+func (t *cacheTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	key := cacheKey(r.URL.String())
+
+	if r.Method != http.MethodGet {
+		resp, err := t.next.RoundTrip(r)
+		if err == nil && resp.StatusCode < 400 {
+			t.cache.remove(key)
+		}
+
+		return resp, err
+	}
+
+	// A streamed response can be very large, so is never cached.
+	if r.Context().Value(noCacheKey{}) != nil {
+		return t.next.RoundTrip(r)
+	}
+
+	requestControl := cacheControl(r.Header)
+	if _, noStore := requestControl["no-store"]; noStore {
+		return t.next.RoundTrip(r)
+	}
+
+	variant := cacheVariant(r)
+
+	entry, found := t.cache.load(key, variant)
+	if found {
+		_, noCache := requestControl["no-cache"]
+
+		if !noCache && !entry.NoCache && time.Now().Before(entry.Expires) {
+			ui.Log(ui.RestLogger, "Cached response for %s used", r.URL)
+
+			return t.cache.response(key, entry, r)
+		}
+
+		// Ask the server if the cached response is still valid.
+		r = r.Clone(r.Context())
+
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			r.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && found {
+		resp.Body.Close()
+
+		// The server may send new cache headers with the reply.
+		for _, name := range []string{"Cache-Control", "Expires", "ETag", "Last-Modified", "Date"} {
+			if value := resp.Header.Get(name); value != "" {
+				entry.Header.Set(name, value)
+			}
+		}
+
+		entry.Expires, entry.NoCache = expiration(entry.Header)
+		t.cache.save(key, entry)
+
+		ui.Log(ui.RestLogger, "Cached response for %s revalidated", r.URL)
+
+		return t.cache.response(key, entry, r)
+	}
+
+	if resp.StatusCode != http.StatusOK || !cacheable(resp.Header) {
+		return resp, nil
+	}
+
+	// If the size of the body is not known, it is cached unless it turns
+	// out to be too large as it is read.
+	maxSize := t.cache.maxEntrySize()
+	if resp.ContentLength > maxSize {
+		ui.Log(ui.RestLogger, "Response for %s is too large to cache", r.URL)
+
+		return resp, nil
+	}
+
+	// Store the body in the cache as it is read by the caller.
+	if err := os.MkdirAll(t.cache.Directory, 0700); err != nil {
+		return resp, nil
+	}
+
+	file, err := os.CreateTemp(t.cache.Directory, "response-*.tmp")
+	if err != nil {
+		return resp, nil
+	}
+
+	entry = cacheEntry{
+		URL:     r.URL.String(),
+		Variant: variant,
+		Status:  resp.StatusCode,
+		Header:  resp.Header.Clone(),
+		Stored:  time.Now(),
+	}
+	entry.Expires, entry.NoCache = expiration(resp.Header)
+
+	resp.Body = &cachingBody{body: resp.Body, file: file, cache: t.cache, key: key, entry: entry, remaining: maxSize}
+
+	return resp, nil
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+
+	if n > 0 && b.file != nil {
+		b.remaining -= int64(n)
+
+		if b.remaining < 0 {
+			b.discard()
+		} else if _, writeErr := b.file.Write(p[:n]); writeErr != nil {
+			b.discard()
+		}
+	}
+
+	if err == io.EOF && b.file != nil && !b.done {
+		b.done = true
+		b.cache.store(b.key, b.entry, b.file)
+		b.file = nil
+	}
+
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	// If the body was not completely read, it is not cached.
+	b.discard()
+
+	return b.body.Close()
+}
+
+// discard abandons the copy of the response body.
+func (b *cachingBody) discard() {
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+		b.file = nil
+	}
+}
+
+// load reads the cached response for a key. The response is not used if it was
+// cached for a request with a different variant.
+func (c *Cache) load(key, variant string) (cacheEntry, bool) {
+	entry := cacheEntry{}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	b, err := os.ReadFile(c.path(key, ".json"))
+	if err != nil {
+		return entry, false
+	}
+
+	if err := json.Unmarshal(b, &entry); err != nil || entry.Variant != variant {
+		return entry, false
+	}
+
+	if _, err := os.Stat(c.path(key, ".body")); err != nil {
+		return entry, false
+	}
+
+	return entry, true
+}
+
+// store saves a response whose body was written to the file.
+func (c *Cache) store(key string, entry cacheEntry, file *os.File) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	file.Close()
+
+	if err := os.Rename(file.Name(), c.path(key, ".body")); err != nil {
+		os.Remove(file.Name())
+
+		return
+	}
+
+	c.saveEntry(key, entry)
+
+	ui.Log(ui.RestLogger, "Response for %s cached", entry.URL)
+}
+
+// save updates the description of a cached response.
+func (c *Cache) save(key string, entry cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.saveEntry(key, entry)
+}
+
+// saveEntry writes the description of a cached response. The caller must hold
+// the cache mutex.
+func (c *Cache) saveEntry(key string, entry cacheEntry) {
+	if b, err := json.MarshalIndent(entry, "", "  "); err == nil {
+		if err := os.WriteFile(c.path(key, ".json"), b, 0600); err != nil {
+			ui.Log(ui.RestLogger, "Unable to cache response for %s, %v", entry.URL, err)
+		}
+	}
+}
+
+// remove discards the cached response for a key.
+func (c *Cache) remove(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if os.Remove(c.path(key, ".json")) == nil {
+		ui.Log(ui.RestLogger, "Cached response removed")
+	}
+
+	os.Remove(c.path(key, ".body"))
+}
+
+// response creates a response for a request using the cached response.
+func (c *Cache) response(key string, entry cacheEntry, r *http.Request) (*http.Response, error) {
+	file, err := os.Open(c.path(key, ".body"))
+	if err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	length := int64(-1)
+	if info, err := file.Stat(); err == nil {
+		length = info.Size()
+	}
+
+	header := entry.Header.Clone()
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+
+	return &http.Response{
+		Status:        http.StatusText(entry.Status),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          file,
+		ContentLength: length,
+		Request:       r,
+	}, nil
+}
+
+func (c *Cache) path(key, extension string) string {
+	return filepath.Join(c.Directory, key+extension)
+}
+
+// cacheKey returns the name used for the cache files for a URL.
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheVariant identifies the request headers that can change the response for
+// the same URL, so a response cached for one user or media type is not returned
+// to another.
+func cacheVariant(r *http.Request) string {
+	var b bytes.Buffer
+
+	for _, name := range []string{"Accept", "Authorization", DefaultAPIKeyHeader, settings.Get(defs.LogonAPIKeyHeaderSetting)} {
+		if name != "" {
+			b.WriteString(r.Header.Get(name))
+			b.WriteString("\n")
+		}
+	}
+
+	sum := sha256.Sum256(b.Bytes())
+
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheControl parses the Cache-Control header into a map of directives.
+func cacheControl(header http.Header) map[string]string {
+	result := map[string]string{}
+
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, argument, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				result[strings.ToLower(name)] = strings.Trim(argument, "\"")
+			}
+		}
+	}
+
+	return result
+}
+
+// cacheable reports whether a response can be cached.
+func cacheable(header http.Header) bool {
+	control := cacheControl(header)
+	if _, noStore := control["no-store"]; noStore {
+		return false
+	}
+
+	if header.Get("ETag") != "" || header.Get("Last-Modified") != "" {
+		return true
+	}
+
+	expires, _ := expiration(header)
+
+	return time.Now().Before(expires)
+}
+
+// expiration returns the time a response expires, and whether the response
+// must always be revalidated before it is used.
+func expiration(header http.Header) (time.Time, bool) {
+	control := cacheControl(header)
+
+	_, noCache := control["no-cache"]
+
+	if age, found := control["max-age"]; found {
+		if seconds, err := strconv.Atoi(age); err == nil {
+			return time.Now().Add(time.Duration(seconds) * time.Second), noCache
+		}
+	}
+
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return expires, noCache
+	}
+
+	return time.Time{}, noCache
+}
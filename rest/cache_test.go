@@ -0,0 +1,161 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+)
+
+func TestCache(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	var calls, notModified int
+
+	// The "/etag" endpoint must be revalidated each time, and "/fresh" can be
+	// used for a minute without asking the server.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+
+			w.Header().Set("ETag", `"v1"`)
+
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+
+		case "/private":
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("ETag", `"v1"`)
+		}
+
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	cache := NewCache(t.TempDir())
+	client := NewClient(server.URL).SetCache(cache)
+
+	tests := []struct {
+		name        string
+		endpoint    string
+		calls       int
+		notModified int
+	}{
+		{name: "etag revalidated", endpoint: "/etag", calls: 3, notModified: 2},
+		{name: "fresh not requested", endpoint: "/fresh", calls: 1},
+		{name: "no-store not cached", endpoint: "/private", calls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, notModified = 0, 0
+
+			for n := 0; n < 3; n++ {
+				response := map[string]interface{}{}
+				if err := client.Exchange(tt.endpoint, http.MethodGet, nil, &response); err != nil || response["path"] != tt.endpoint {
+					t.Fatalf("Exchange() = %v, %v", response, err)
+				}
+			}
+
+			if calls != tt.calls || notModified != tt.notModified {
+				t.Errorf("server calls = %d, not modified = %d; want %d, %d", calls, notModified, tt.calls, tt.notModified)
+			}
+		})
+	}
+
+	// Changing the resource removes the cached response.
+	calls = 0
+	_ = client.Exchange("/fresh", http.MethodPut, nil, &map[string]interface{}{})
+	_ = client.Exchange("/fresh", http.MethodGet, nil, &map[string]interface{}{})
+
+	if calls != 2 {
+		t.Errorf("server calls after PUT = %d, want 2", calls)
+	}
+
+	if count, err := cache.Clear(); err != nil || count != 2 {
+		t.Errorf("Clear() = %d, %v; want 2", count, err)
+	}
+}
+
+func TestCacheLimits(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	large := `{"data":"` + strings.Repeat("x", 64) + `"}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+
+		switch r.URL.Path {
+		case "/large":
+			w.Header().Set("Content-Length", strconv.Itoa(len(large)))
+			_, _ = w.Write([]byte(large))
+
+		case "/chunked":
+			// Flushing sends the body without a Content-Length.
+			_, _ = w.Write([]byte(large[:40]))
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(large[40:]))
+
+		default:
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer server.Close()
+
+	directory := t.TempDir()
+	cache := NewCache(directory)
+	cache.MaxEntrySize = 32
+	client := NewClient(server.URL).SetCache(cache)
+
+	for _, endpoint := range []string{"/large", "/chunked"} {
+		response := map[string]interface{}{}
+		if err := client.Exchange(endpoint, http.MethodGet, nil, &response); err != nil || response["data"] == nil {
+			t.Fatalf("Exchange(%s) = %v, %v", endpoint, response, err)
+		}
+	}
+
+	var b bytes.Buffer
+	if _, err := client.Download(context.Background(), "/download", &b, nil); err != nil || b.String() != `{"ok":true}` {
+		t.Fatalf("Download() = %q, %v", b.String(), err)
+	}
+
+	if files, _ := os.ReadDir(directory); len(files) != 0 {
+		t.Errorf("cache directory has %d files, want none", len(files))
+	}
+
+	// A small response is still cached.
+	response := map[string]interface{}{}
+	if err := client.Exchange("/small", http.MethodGet, nil, &response); err != nil {
+		t.Fatalf("Exchange(/small) = %v", err)
+	}
+
+	if files, _ := filepath.Glob(filepath.Join(directory, "*.json")); len(files) != 1 {
+		t.Errorf("cache directory has %d entries, want 1", len(files))
+	}
+}
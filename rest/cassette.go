@@ -0,0 +1,460 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+// These are the modes of a cassette.
+const (
+	// RecordMode sends requests to the server, and records each request and
+	// response in the cassette.
+	RecordMode = "record"
+
+	// ReplayMode does not contact a server. The response to each request is
+	// taken from the cassette.
+	ReplayMode = "replay"
+)
+
+// These are the ways a replayed request is matched to a recorded one.
+const (
+	// MatchInOrder replays the recorded responses in the order they were
+	// recorded, regardless of the request.
+	MatchInOrder = "order"
+
+	// MatchRequest replays the first unused recording with the same method,
+	// URL path and query, and body as the request.
+	MatchRequest = "request"
+)
+
+// The value that replaces secrets in a recording.
+const redacted = "REDACTED"
+
+// Headers whose values are never written to a cassette.
+var redactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	DefaultAPIKeyHeader,
+}
+
+// Body fields, in JSON or form-encoded bodies, whose values are never written
+// to a cassette.
+var redactedFields = []string{
+	"access_token",
+	"client_secret",
+	"password",
+	"refresh_token",
+	"secret",
+	"token",
+}
+
+// RecordedRequest is a request stored in a cassette.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	Base64 bool        `json:"base64,omitempty"`
+}
+
+// RecordedResponse is a response stored in a cassette.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	Base64 bool        `json:"base64,omitempty"`
+}
+
+// Interaction is a request and the response it received.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette records REST requests and their responses in a file, or replays
+// them from the file without contacting a server. Use OpenCassette() to
+// create a cassette, and Client.SetCassette() to use it for a client. All
+// clients use the cassette named in the profile, if there is one.
+type Cassette struct {
+	// The file the interactions are stored in.
+	Path string `json:"-"`
+
+	// The mode, either RecordMode or ReplayMode.
+	Mode string `json:"-"`
+
+	// How replayed requests are matched, either MatchInOrder or MatchRequest.
+	Match string `json:"-"`
+
+	// The recorded interactions.
+	Interactions []Interaction `json:"interactions"`
+
+	used  []bool
+	next  int
+	mutex sync.Mutex
+}
+
+// cassetteTransport sends requests through a cassette.
+type cassetteTransport struct {
+	cassette *Cassette
+	next     http.RoundTripper
+}
+
+// The cassettes named in the profile, keyed by file name.
+var cassettes = map[string]*Cassette{}
+var cassettesMutex sync.Mutex
+
+// OpenCassette creates a cassette using the given file. In record mode, any
+// existing recording in the file is replaced. In replay mode, the file must
+// already exist.
+func OpenCassette(path, mode string) (*Cassette, error) {
+	c := &Cassette{
+		Path:  path,
+		Mode:  strings.ToLower(mode),
+		Match: MatchInOrder,
+	}
+
+	switch c.Mode {
+	case RecordMode:
+		return c, nil
+
+	case ReplayMode:
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.NewError(err)
+		}
+
+		if err := json.Unmarshal(b, c); err != nil {
+			return nil, errors.NewError(err).Context(path)
+		}
+
+		c.used = make([]bool, len(c.Interactions))
+
+		ui.Log(ui.RestLogger, "Replaying %d REST interactions from %s", len(c.Interactions), path)
+
+		return c, nil
+
+	default:
+		return nil, errors.ErrInvalidCassetteMode.Context(mode)
+	}
+}
+
+// ProfileCassette returns the cassette named by the profile setting or the
+// environment variable, or nil if there isn't one. The same cassette is
+// returned each time for the same file.
+func ProfileCassette() (*Cassette, error) {
+	path := envDefault("APP_REST_CASSETTE", settings.Get(defs.RestCassetteSetting))
+	if path == "" {
+		return nil, nil
+	}
+
+	cassettesMutex.Lock()
+	defer cassettesMutex.Unlock()
+
+	if c, found := cassettes[path]; found {
+		return c, nil
+	}
+
+	c, err := OpenCassette(path, envDefault("APP_REST_CASSETTE_MODE", settingDefault(defs.RestCassetteModeSetting, ReplayMode)))
+	if err != nil {
+		return nil, err
+	}
+
+	c.Match = strings.ToLower(envDefault("APP_REST_CASSETTE_MATCH", settingDefault(defs.RestCassetteMatchSetting, MatchInOrder)))
+	cassettes[path] = c
+
+	return c, nil
+}
+
+// Transport returns a transport that records requests sent using the next
+// transport, or replays them, depending on the mode of the cassette.
+func (c *Cassette) Transport(next http.RoundTripper) http.RoundTripper {
+	return &cassetteTransport{cassette: c, next: next}
+}
+
+// unwrap returns the transport that sends the requests that are recorded.
+func (t *cassetteTransport) unwrap() http.RoundTripper {
+	return t.next
+}
+
+// Save writes the recorded interactions to the cassette file.
+func (c *Cassette) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.save()
+}
+
+func (c *Cassette) save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.NewError(err)
+	}
+
+	if err := os.WriteFile(c.Path, b, 0600); err != nil {
+		return errors.NewError(err)
+	}
+
+	return nil
+}
+
+func (t *cassetteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.cassette.Mode == ReplayMode {
+		return t.cassette.replay(r, body)
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		return resp, err
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	return resp, t.cassette.record(r, body, resp, responseBody)
+}
+
+// record adds an interaction to the cassette, and saves the cassette so the
+// recording is complete even if the program exits without closing it.
+func (c *Cassette) record(r *http.Request, body []byte, resp *http.Response, responseBody []byte) error {
+	i := Interaction{
+		Request: RecordedRequest{
+			Method: r.Method,
+			URL:    r.URL.String(),
+			Header: redactHeader(r.Header),
+		},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: redactHeader(resp.Header),
+		},
+	}
+
+	i.Request.Body, i.Request.Base64 = encodeBody(redactBody(body, r.Header.Get("Content-Type")))
+	i.Response.Body, i.Response.Base64 = encodeBody(redactBody(responseBody, resp.Header.Get("Content-Type")))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.Interactions = append(c.Interactions, i)
+
+	ui.Log(ui.RestLogger, "Recorded %s %s in %s", r.Method, r.URL, c.Path)
+
+	return c.save()
+}
+
+// replay finds the recorded response for a request.
+func (c *Cassette) replay(r *http.Request, body []byte) (*http.Response, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	found := -1
+
+	if c.Match == MatchRequest {
+		redactedBody, _ := encodeBody(redactBody(body, r.Header.Get("Content-Type")))
+
+		for n, i := range c.Interactions {
+			if !c.used[n] && i.Request.matches(r, redactedBody) {
+				found = n
+
+				break
+			}
+		}
+	} else if c.next < len(c.Interactions) {
+		found = c.next
+		c.next++
+	}
+
+	if found < 0 {
+		return nil, errors.ErrRestNoRecording.Context(r.Method + " " + r.URL.RequestURI())
+	}
+
+	c.used[found] = true
+	recorded := c.Interactions[found].Response
+
+	ui.Log(ui.RestLogger, "Replayed %s %s from %s", r.Method, r.URL, c.Path)
+
+	responseBody, err := decodeBody(recorded.Body, recorded.Base64)
+	if err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	header := recorded.Header
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(recorded.Status),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       r,
+	}, nil
+}
+
+// matches reports whether a request has the same method, URL path and query,
+// and body as the recording. The host is not compared, so recordings can be
+// replayed against any server address.
+func (rr RecordedRequest) matches(r *http.Request, body string) bool {
+	if !strings.EqualFold(rr.Method, r.Method) || rr.Body != body {
+		return false
+	}
+
+	u, err := url.Parse(rr.URL)
+
+	return err == nil && u.RequestURI() == r.URL.RequestURI()
+}
+
+// readRequestBody reads the body of a request, so it can be recorded.
+func readRequestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	defer r.Body.Close()
+
+	return io.ReadAll(r.Body)
+}
+
+// redactHeader returns a copy of the header with the secret values replaced.
+func redactHeader(header http.Header) http.Header {
+	result := header.Clone()
+
+	names := append(redactedHeaders, settings.Get(defs.LogonAPIKeyHeaderSetting))
+	for _, name := range names {
+		if name != "" && result.Get(name) != "" {
+			result.Set(name, redacted)
+		}
+	}
+
+	return result
+}
+
+// redactBody replaces the values of secret fields in a JSON or form-encoded
+// body. Other bodies are returned unchanged.
+func redactBody(body []byte, mediaType string) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	if strings.HasPrefix(mediaType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+
+		for _, field := range redactedFields {
+			if values.Has(field) {
+				values.Set(field, redacted)
+			}
+		}
+
+		return []byte(values.Encode())
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return body
+	}
+
+	if !redactValue(value) {
+		return body
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+
+	return b
+}
+
+// redactValue replaces secret fields anywhere in a decoded JSON value. The
+// result is true if anything was replaced.
+func redactValue(value interface{}) bool {
+	changed := false
+
+	switch actual := value.(type) {
+	case map[string]interface{}:
+		for key, item := range actual {
+			for _, field := range redactedFields {
+				if strings.EqualFold(key, field) {
+					actual[key] = redacted
+					changed = true
+				}
+			}
+
+			if redactValue(item) {
+				changed = true
+			}
+		}
+
+	case []interface{}:
+		for _, item := range actual {
+			if redactValue(item) {
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// encodeBody converts a body to a string for the cassette file. A body that
+// is not valid UTF-8 text is base64 encoded.
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+func decodeBody(body string, isBase64 bool) ([]byte, error) {
+	if isBase64 {
+		return base64.StdEncoding.DecodeString(body)
+	}
+
+	return []byte(body), nil
+}
+
+// settingDefault returns the profile setting, or the default value if the
+// setting is empty.
+func settingDefault(key, defaultValue string) string {
+	if value := settings.Get(key); value != "" {
+		return value
+	}
+
+	return defaultValue
+}
@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+func TestCassette(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `","token":"server-secret"}`))
+	}))
+
+	// Record two requests, using a credential and a body with a password.
+	recorder, err := OpenCassette(path, RecordMode)
+	if err != nil {
+		t.Fatalf("OpenCassette(record) unexpected error %v", err)
+	}
+
+	client := NewClient(server.URL).
+		SetCassette(recorder).
+		SetCredential(Credential{Token: "client-secret", Method: TokenAuthentication})
+
+	for _, endpoint := range []string{"/first", "/second"} {
+		body := map[string]interface{}{"user": "joe", "password": "joe-secret"}
+		if err := client.Exchange(endpoint, http.MethodPost, body, &map[string]interface{}{}); err != nil {
+			t.Fatalf("Exchange(%s) unexpected error %v", endpoint, err)
+		}
+	}
+
+	server.Close()
+
+	b, _ := os.ReadFile(path)
+	for _, secret := range []string{"client-secret", "joe-secret", "server-secret"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains secret %q", secret)
+		}
+	}
+
+	// Replay the requests from the profile setting, without the server.
+	settings.Set(defs.RestCassetteSetting, path)
+	settings.Set(defs.RestCassetteMatchSetting, MatchRequest)
+
+	defer func() {
+		_ = settings.Delete(defs.RestCassetteSetting)
+		_ = settings.Delete(defs.RestCassetteMatchSetting)
+	}()
+
+	replay := NewClient(server.URL)
+
+	for _, endpoint := range []string{"/second", "/first"} {
+		response := map[string]interface{}{}
+		body := map[string]interface{}{"user": "joe", "password": "other-secret"}
+
+		if err := replay.Exchange(endpoint, http.MethodPost, body, &response); err != nil || response["path"] != endpoint {
+			t.Errorf("Exchange(%s) replayed %v, %v", endpoint, response["path"], err)
+		}
+	}
+
+	if err := replay.Exchange("/first", http.MethodPost, nil, nil); !errors.Equals(err, errors.ErrRestNoRecording) {
+		t.Errorf("Exchange(unrecorded) error = %v, want no recording", err)
+	}
+}
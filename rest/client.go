@@ -0,0 +1,559 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/expressions/data"
+	"github.com/tucats/gopackages/util"
+	"gopkg.in/resty.v1"
+)
+
+// DefaultServer is the server used when no server is given and there is no
+// logon server in the profile.
+const DefaultServer = "http://localhost:8080"
+
+// Client sends REST requests to a single server. The underlying HTTP client,
+// and therefore its connections, are reused for every request made with the
+// Client. A Client is safe for use by multiple goroutines.
+//
+// Create a Client with NewClient(), and then use the Set...() functions to
+// change the defaults before making the first request.
+type Client struct {
+	// The base URL of the server. The endpoint of each request is added to
+	// this URL.
+	baseURL string
+
+	// The agent type added to the User-Agent header of each request.
+	agent string
+
+	// The logger used to report the requests.
+	logger int
+
+	// Headers added to every request.
+	headers map[string]string
+
+	// If not nil, the credential used to authenticate every request. If nil,
+	// the credential stored in the profile for the server is used.
+	credential *Credential
+
+	// If not nil, the request policy used for requests that do not have a
+	// policy in their context.
+	policy *RequestPolicy
+
+	// If not nil, the TLS configuration for the connections. If nil, a copy
+	// of the configuration from GetTLSConfiguration() is used.
+	tlsConfig *tls.Config
+
+	// If not nil, the cassette used to record or replay the requests. If nil,
+	// the cassette named in the profile is used, if there is one.
+	cassette *Cassette
+
+	// If not nil, the cache used for GET requests. If nil, the cache in the
+	// profile directory is used if caching is enabled in the profile.
+	cache *Cache
+
+	// The HTTP client, created when the first request is made.
+	client *resty.Client
+	mutex  sync.Mutex
+}
+
+// The clients used by Exchange() and ServerExchange(), keyed by server.
+var clients = map[string]*Client{}
+var clientsMutex sync.Mutex
+
+// The client used by Exchange(), if one has been set with the function
+// SetDefaultClient().
+var defaultClient *Client
+
+// NewClient creates a client for the server with the given base URL. If the
+// URL is empty, the default logon server from the profile is used.
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = settings.Get(defs.LogonServerSetting)
+	}
+
+	if baseURL == "" {
+		baseURL = DefaultServer
+	}
+
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		agent:   defs.TableAgent,
+		logger:  ui.RestLogger,
+		headers: map[string]string{},
+	}
+}
+
+// DefaultClient returns the client used by Exchange(). This is the client set
+// by SetDefaultClient(), or a shared client for the default logon server.
+func DefaultClient() *Client {
+	clientsMutex.Lock()
+	c := defaultClient
+	clientsMutex.Unlock()
+
+	if c != nil {
+		return c
+	}
+
+	return clientFor(settings.Get(defs.LogonServerSetting))
+}
+
+// SetDefaultClient sets the client used by Exchange(), and returns the client
+// that was previously set. If the client is nil, Exchange() uses a shared
+// client for the default logon server. This is most often used to inject a
+// client for testing.
+func SetDefaultClient(c *Client) *Client {
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	previous := defaultClient
+	defaultClient = c
+
+	return previous
+}
+
+// clientFor returns the shared client for the given server, creating it if
+// needed.
+func clientFor(server string) *Client {
+	if server == "" {
+		server = DefaultServer
+	}
+
+	key := normalizeServer(server)
+
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	c, found := clients[key]
+	if !found {
+		c = NewClient(server)
+		clients[key] = c
+	}
+
+	return c
+}
+
+// BaseURL returns the base URL of the server the client sends requests to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// SetAgent sets the agent type added to the User-Agent header.
+func (c *Client) SetAgent(agent string) *Client {
+	c.agent = agent
+
+	return c
+}
+
+// SetLogger sets the logger used to report the requests made by the client.
+func (c *Client) SetLogger(logger int) *Client {
+	c.logger = logger
+
+	return c
+}
+
+// SetHeader sets a header that is added to every request.
+func (c *Client) SetHeader(name, value string) *Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.headers[name] = value
+
+	return c
+}
+
+// SetCredential sets the credential used to authenticate every request. This
+// replaces the use of the credential stored in the profile for the server.
+func (c *Client) SetCredential(credential Credential) *Client {
+	c.credential = &credential
+
+	return c
+}
+
+// SetPolicy sets the request policy used for requests whose context does not
+// specify a policy.
+func (c *Client) SetPolicy(policy RequestPolicy) *Client {
+	c.policy = &policy
+
+	return c
+}
+
+// SetTLSConfig sets the TLS configuration used for connections to the server.
+// This must be called before the first request is made.
+func (c *Client) SetTLSConfig(config *tls.Config) *Client {
+	c.tlsConfig = config
+
+	return c
+}
+
+// SetCassette sets the cassette used to record or replay the requests made by
+// the client. This must be called before the first request is made.
+func (c *Client) SetCassette(cassette *Cassette) *Client {
+	c.cassette = cassette
+
+	return c
+}
+
+// SetCache sets the cache used for the GET requests made by the client. This
+// must be called before the first request is made.
+func (c *Client) SetCache(cache *Cache) *Client {
+	c.cache = cache
+
+	return c
+}
+
+// Exchange sends a request to an endpoint of the server. If the body is not
+// nil, it is sent as the JSON payload. If the response is not nil, the reply
+// is decoded into it. The optional media types are the receiving and sending
+// media types respectively; the default for both is JSON.
+func (c *Client) Exchange(endpoint, method string, body interface{}, response interface{}, mediaTypes ...string) error {
+	return c.exchange(context.Background(), endpoint, method, body, response, c.agent, mediaTypes...)
+}
+
+// ExchangeWithContext is the same as Exchange, but the request is cancelled if
+// the context is cancelled or its deadline passes.
+func (c *Client) ExchangeWithContext(ctx context.Context, endpoint, method string, body interface{}, response interface{}, mediaTypes ...string) error {
+	return c.exchange(ctx, endpoint, method, body, response, c.agent, mediaTypes...)
+}
+
+// httpClient returns the HTTP client, creating it when the first request is
+// made.
+func (c *Client) httpClient() (*resty.Client, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	cassette := c.cassette
+	if cassette == nil {
+		var err error
+
+		if cassette, err = ProfileCassette(); err != nil {
+			return nil, err
+		}
+	}
+
+	// A replayed request does not contact the server, so the TLS and proxy
+	// configuration is only needed if the requests are sent.
+	var client *resty.Client
+
+	if cassette != nil && cassette.Mode == ReplayMode {
+		client = resty.New()
+	} else {
+		var err error
+
+		if client, err = newRestClient(c.tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	client.SetRedirectPolicy(resty.FlexibleRedirectPolicy(MaxRedirectCount))
+
+	if cassette != nil {
+		client.SetTransport(cassette.Transport(client.GetClient().Transport))
+	}
+
+	cache := c.cache
+	if cache == nil {
+		var err error
+
+		if cache, err = ProfileCache(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cache != nil {
+		client.SetTransport(cache.Transport(client.GetClient().Transport))
+	}
+
+	c.client = client
+
+	return c.client, nil
+}
+
+// requestPolicy returns the policy from the context. If the context does not
+// have one, the client policy or the default policy is used.
+func (c *Client) requestPolicy(ctx context.Context) RequestPolicy {
+	if c.policy != nil && ctx.Value(policyKey{}) == nil {
+		return *c.policy
+	}
+
+	return requestPolicy(ctx)
+}
+
+// authenticate adds the client credential to the request. If the client does
+// not have a credential, the one stored in the profile for the server is used.
+func (c *Client) authenticate(client *resty.Client, r *resty.Request, checkExpiration bool) error {
+	if c.credential == nil {
+		return authenticate(c.baseURL, client, r, checkExpiration)
+	}
+
+	a, err := GetAuthenticator(c.credential.method())
+	if err != nil {
+		return err
+	}
+
+	return a.Authenticate(*c.credential, client, r)
+}
+
+// request describes a single REST request made by a client.
+type request struct {
+	endpoint string
+	method   string
+
+	// The request body. An io.Reader is sent as-is; any other value is sent
+	// as JSON.
+	body interface{}
+
+	// The agent type added to the User-Agent header.
+	agent string
+
+	// The receiving and sending media types respectively.
+	mediaTypes []string
+
+	// If true, the response body is not read. The caller must read and close
+	// the raw body of the response.
+	stream bool
+}
+
+// exchange sends the request, and processes the response. If the response object
+// is not nil, the reply is decoded into it.
+func (c *Client) exchange(ctx context.Context, endpoint, method string, body interface{}, response interface{}, agentType string, mediaTypes ...string) error {
+	resp, err := c.send(ctx, request{
+		endpoint:   endpoint,
+		method:     method,
+		body:       body,
+		agent:      agentType,
+		mediaTypes: mediaTypes,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.response(resp, response)
+}
+
+// send sends the request, retrying it as allowed by the request policy.
+//
+// Each attempt to send the request is limited by the policy timeout. If an attempt
+// fails with a network error or a status that indicates a transient condition (such
+// as 502 or 503), and the method is idempotent, the request is retried after a delay
+// that increases exponentially with each attempt. If the server sends a Retry-After
+// header, that delay is used instead, unless it is longer than the maximum delay of
+// the policy, in which case the request is not retried.
+//
+// A streamed request is not limited by the policy timeout, since the response body
+// is read after send returns; use the context to limit it instead. A request body
+// that is an io.Reader is only retried if it is also an io.Seeker, so it can be
+// read again from the start.
+func (c *Client) send(ctx context.Context, req request) (*resty.Response, error) {
+	var resp *resty.Response
+
+	var err error
+
+	var b []byte
+
+	url := c.baseURL + req.endpoint
+	policy := c.requestPolicy(ctx)
+
+	ui.Log(c.logger, "%s %s", strings.ToUpper(req.method), url)
+
+	client, err := c.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// Lets figure out what media types we're sending and reciving. By default, they
+	// are anonymous JSON. But if the call included one or two strings, they are used
+	// as the receiving and sending media types respectively.
+	receiveMediaType := defs.JSONMediaType
+	sendMediaType := defs.JSONMediaType
+
+	if len(req.mediaTypes) > 0 {
+		receiveMediaType = req.mediaTypes[0]
+
+		ui.Log(c.logger, "Adding media type: %s", receiveMediaType)
+	}
+
+	if len(req.mediaTypes) > 1 {
+		sendMediaType = req.mediaTypes[1]
+
+		ui.Log(c.logger, "Adding media type: %s", sendMediaType)
+	}
+
+	reader, isReader := req.body.(io.Reader)
+	if isReader {
+		ui.Log(c.logger, "Request payload streamed from %T", reader)
+
+		if _, ok := reader.(io.Seeker); !ok {
+			policy.Retries = 0
+		}
+	} else if req.body != nil {
+		b, err = json.MarshalIndent(req.body, "", "  ")
+		if err != nil {
+			return nil, errors.NewError(err)
+		}
+
+		ui.Log(c.logger, "Request payload:\n%s", string(b))
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptContext, cancel := ctx, context.CancelFunc(func() {})
+		if policy.Timeout > 0 && !req.stream {
+			attemptContext, cancel = context.WithTimeout(ctx, policy.Timeout)
+		}
+
+		if req.stream {
+			attemptContext = context.WithValue(attemptContext, noCacheKey{}, true)
+		}
+
+		r := client.NewRequest().SetContext(attemptContext).SetDoNotParseResponse(req.stream)
+
+		// Unless this is a open (un-authenticate) service, add the authentication
+		// for the server to the request. This verifies that the authentication
+		// token is still valid. Note we skip the expiration check if the agent
+		// string is "status".
+		if util.InList(req.endpoint, openServices...) {
+			ui.Log(c.logger, "Endpoint %s does not require token", req.endpoint)
+		} else if err := c.authenticate(client, r, !strings.EqualFold(req.agent, defs.StatusAgent)); err != nil {
+			cancel()
+
+			return nil, err
+		}
+
+		c.mutex.Lock()
+		for name, value := range c.headers {
+			r.Header.Set(name, value)
+		}
+		c.mutex.Unlock()
+
+		r.Header.Add("Content-Type", sendMediaType)
+		r.Header.Add("Accept", receiveMediaType)
+		AddAgent(r, req.agent)
+
+		if isReader {
+			r.SetBody(reader)
+		} else if b != nil {
+			r.SetBody(b)
+		}
+
+		resp, err = r.Execute(req.method, url)
+
+		// Resty has already read the response body, so the attempt context
+		// can be released. A streamed request does not have its own attempt
+		// context, so its body can still be read.
+		cancel()
+
+		// If the context was cancelled or timed out, don't bother retrying.
+		if ctx.Err() != nil {
+			ui.Log(c.logger, "REST cancelled, %v", ctx.Err())
+			closeRawBody(resp)
+
+			return nil, errors.NewError(ctx.Err())
+		}
+
+		delay, retry := policy.retryDelay(req.method, attempt, resp, err)
+		if !retry {
+			break
+		}
+
+		// A streamed body must be read again from the start. If that isn't
+		// possible, the result of this attempt is the result of the request.
+		if isReader {
+			if _, seekErr := reader.(io.Seeker).Seek(0, io.SeekStart); seekErr != nil {
+				ui.Log(c.logger, "REST request body cannot be rewound, %v", seekErr)
+
+				break
+			}
+		}
+
+		if err != nil {
+			ui.Log(c.logger, "REST attempt %d failed, %v; retrying in %v", attempt+1, err, delay)
+		} else {
+			ui.Log(c.logger, "REST attempt %d status %d; retrying in %v", attempt+1, resp.StatusCode(), delay)
+		}
+
+		closeRawBody(resp)
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, errors.NewError(err)
+		}
+	}
+
+	if err != nil {
+		ui.Log(c.logger, "REST failed, %v", err)
+		closeRawBody(resp)
+
+		return nil, errors.NewError(err)
+	}
+
+	return resp, nil
+}
+
+// response processes the reply from the server. If the reply status is not in
+// the 2xx range, an HTTPError describing the reply is returned. Otherwise, if
+// there is a response object, the reply is decoded into it.
+func (c *Client) response(resp *resty.Response, response interface{}) error {
+	var err error
+
+	status := resp.StatusCode()
+
+	ui.Log(c.logger, "Status: %d", status)
+
+	if replyMedia := resp.Header().Get("Content-Type"); replyMedia != "" {
+		ui.Log(c.logger, "Reply media type: %s", replyMedia)
+	}
+
+	if status < 200 || status > 299 {
+		ui.Log(c.logger, "Response payload:\n%v", string(resp.Body()))
+
+		return errors.NewError(newHTTPError(resp, resp.Body()))
+	}
+
+	if response != nil {
+		body := string(resp.Body())
+		if body != "" {
+			if !util.InList(body[0:1], "{", "[", "\"") {
+				r := defs.RestStatusResponse{
+					Status:  resp.StatusCode(),
+					Message: strings.TrimSuffix(body, "\n"),
+				}
+				b, _ := json.Marshal(r)
+				body = string(b)
+			}
+
+			err = json.Unmarshal([]byte(body), response)
+			if err == nil && ui.IsActive(c.logger) {
+				responseBytes, _ := json.MarshalIndent(response, "", "  ")
+
+				ui.Log(c.logger, "Response payload:\n%s", string(responseBytes))
+			}
+
+			if err == nil && status != http.StatusOK {
+				if m, ok := response.(map[string]interface{}); ok {
+					if msg, ok := m["Message"]; ok {
+						err = errors.NewMessage(data.String(msg))
+					}
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		err = errors.NewError(err)
+	}
+
+	return err
+}
@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+)
+
+func TestClient(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	// Each server echoes its name, and the token and header it received.
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", defs.JSONMediaType)
+			_, _ = w.Write([]byte(`{"name":"` + name + `","auth":"` + r.Header.Get("Authorization") + `","header":"` + r.Header.Get("X-Test") + `"}`))
+		}))
+	}
+
+	one := newServer("one")
+	defer one.Close()
+
+	two := newServer("two")
+	defer two.Close()
+
+	clientOne := NewClient(one.URL).
+		SetCredential(Credential{Token: "token-one", Method: TokenAuthentication}).
+		SetHeader("X-Test", "first")
+
+	clientTwo := NewClient(two.URL + "/").
+		SetCredential(Credential{Token: "token-two", Method: TokenAuthentication})
+
+	tests := []struct {
+		name   string
+		client *Client
+		want   map[string]interface{}
+	}{
+		{
+			name:   "first server",
+			client: clientOne,
+			want:   map[string]interface{}{"name": "one", "auth": "Bearer token-one", "header": "first"},
+		},
+		{
+			name:   "second server",
+			client: clientTwo,
+			want:   map[string]interface{}{"name": "two", "auth": "Bearer token-two", "header": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := map[string]interface{}{}
+
+			if err := tt.client.Exchange("/services/test", http.MethodGet, nil, &response); err != nil {
+				t.Fatalf("Exchange() unexpected error %v", err)
+			}
+
+			for key, value := range tt.want {
+				if response[key] != value {
+					t.Errorf("Exchange() %s = %v, want %v", key, response[key], value)
+				}
+			}
+		})
+	}
+
+	// The HTTP client is created once and reused.
+	first, _ := clientOne.httpClient()
+	if second, _ := clientOne.httpClient(); first != second {
+		t.Errorf("httpClient() created a new client for a second request")
+	}
+
+	// The global Exchange function uses an injected default client.
+	saved := SetDefaultClient(clientTwo)
+	defer SetDefaultClient(saved)
+
+	response := map[string]interface{}{}
+	if err := Exchange("/services/test", http.MethodGet, nil, &response, defs.TableAgent); err != nil || response["name"] != "two" {
+		t.Errorf("Exchange() = %v, %v; want server two", response["name"], err)
+	}
+}
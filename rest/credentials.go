@@ -0,0 +1,336 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+// DefaultRefreshWindow is how long before a token expires that it will be
+// refreshed, if the window has not been set using the profile setting
+// defs.LogonRefreshWindowSetting.
+const DefaultRefreshWindow = 5 * time.Minute
+
+// Credential describes the authentication token for a single server. The
+// credentials for all servers the user has logged on to are stored in the
+// profile, keyed by the server URL.
+type Credential struct {
+	// The base URL of the server that issued the token.
+	Server string `json:"server"`
+
+	// The name of the user that logged on to the server.
+	User string `json:"user,omitempty"`
+
+	// The bearer token to use for requests to this server.
+	Token string `json:"token"`
+
+	// The expiration time of the token, expressed as a string in the
+	// time.UnixDate format. If empty, the token does not expire.
+	Expiration string `json:"expires,omitempty"`
+
+	// The name of the authentication method used to get this credential.
+	// If empty, the token method is assumed.
+	Method string `json:"method,omitempty"`
+
+	// A token that can be used to get a replacement for an expired token,
+	// for authentication methods that support it.
+	RefreshToken string `json:"refresh,omitempty"`
+}
+
+// credentialsMutex serializes access to the credential store, which is
+// shared by all clients in the process.
+var credentialsMutex sync.Mutex
+
+// Expires returns the expiration time of the credential. If the credential
+// has no expiration, the zero time is returned.
+func (c Credential) Expires() (time.Time, error) {
+	if c.Expiration == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.UnixDate, c.Expiration)
+	if err != nil {
+		return t, errors.NewError(err)
+	}
+
+	return t, nil
+}
+
+// method returns the name of the authentication method for the credential.
+func (c Credential) method() string {
+	if c.Method == "" {
+		return TokenAuthentication
+	}
+
+	return c.Method
+}
+
+// SetCredential stores the credential for a server in the profile. Any
+// existing credential for the same server is replaced. If the server is
+// the default logon server, the token and expiration are also stored in
+// the default token settings.
+func SetCredential(c Credential) {
+	credentialsMutex.Lock()
+	defer credentialsMutex.Unlock()
+
+	c.Server = normalizeServer(c.Server)
+	credentials := readCredentials()
+	credentials[c.Server] = c
+
+	writeCredentials(credentials)
+
+	if c.Server == normalizeServer(settings.Get(defs.LogonServerSetting)) {
+		settings.Set(defs.LogonTokenSetting, c.Token)
+		settings.Set(defs.LogonTokenExpirationSetting, c.Expiration)
+	}
+}
+
+// GetCredential returns the stored credential for a server. If there is no
+// credential stored for the server, but it is the default logon server and
+// there is a default token setting, that token is returned.
+func GetCredential(server string) (Credential, bool) {
+	credentialsMutex.Lock()
+	defer credentialsMutex.Unlock()
+
+	server = normalizeServer(server)
+
+	if c, found := readCredentials()[server]; found {
+		return c, true
+	}
+
+	if server == normalizeServer(settings.Get(defs.LogonServerSetting)) {
+		if token := settings.Get(defs.LogonTokenSetting); token != "" {
+			return Credential{
+				Server:     server,
+				Token:      token,
+				Expiration: settings.Get(defs.LogonTokenExpirationSetting),
+			}, true
+		}
+	}
+
+	return Credential{}, false
+}
+
+// DeleteCredential removes the stored credential for a server. The return
+// value indicates if there was a credential to remove.
+func DeleteCredential(server string) bool {
+	credentialsMutex.Lock()
+	defer credentialsMutex.Unlock()
+
+	server = normalizeServer(server)
+	credentials := readCredentials()
+	_, found := credentials[server]
+
+	if found {
+		delete(credentials, server)
+		writeCredentials(credentials)
+	}
+
+	if server == normalizeServer(settings.Get(defs.LogonServerSetting)) {
+		if settings.Exists(defs.LogonTokenSetting) {
+			_ = settings.Delete(defs.LogonTokenSetting)
+			_ = settings.Delete(defs.LogonTokenExpirationSetting)
+			found = true
+		}
+	}
+
+	return found
+}
+
+// Credentials returns the list of stored credentials, sorted by server.
+func Credentials() []Credential {
+	credentialsMutex.Lock()
+	defer credentialsMutex.Unlock()
+
+	credentials := readCredentials()
+	result := make([]Credential, 0, len(credentials))
+
+	for _, c := range credentials {
+		result = append(result, c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Server < result[j].Server
+	})
+
+	return result
+}
+
+// RevokeCredential asks the server that issued the stored credential to
+// revoke its token. The stored credential is not removed; use the function
+// DeleteCredential() to do that.
+func RevokeCredential(server string) error {
+	c, found := GetCredential(server)
+	if !found || c.Token == "" {
+		return errors.ErrNotLoggedOn.Context(server)
+	}
+
+	url := strings.TrimSuffix(c.Server, "/") + defs.ServicesLogoutPath
+
+	client, err := newRestClient(nil)
+	if err != nil {
+		return err
+	}
+
+	client.SetDisableWarn(true)
+
+	req := client.NewRequest().SetAuthToken(c.Token)
+	req.Header.Set("Accept", defs.JSONMediaType)
+	AddAgent(req, defs.LogonAgent)
+
+	r, err := req.Post(url)
+	if err != nil {
+		return errors.NewError(err)
+	}
+
+	ui.Log(ui.RestLogger, "REST POST %s; status %d", url, r.StatusCode())
+
+	if r.StatusCode() != http.StatusOK && r.StatusCode() != http.StatusNoContent {
+		return errors.ErrHTTP.Context(r.StatusCode())
+	}
+
+	return nil
+}
+
+// activeCredential returns the stored credential to use for a request to the
+// given server. If the credential expires within the refresh window, and the
+// authentication method supports it, an attempt is made to refresh it. If the
+// credential has expired and cannot be refreshed, an error is returned. The
+// boolean result is false if there is no stored credential for the server.
+func activeCredential(server string, checkExpiration bool) (Credential, bool, error) {
+	c, found := GetCredential(server)
+	if !found {
+		return c, false, nil
+	}
+
+	if !checkExpiration || c.Expiration == "" {
+		return c, true, nil
+	}
+
+	expires, err := c.Expires()
+	if err != nil {
+		return c, true, err
+	}
+
+	remaining := time.Until(expires)
+	if remaining > refreshWindow() {
+		return c, true, nil
+	}
+
+	if a, _ := GetAuthenticator(c.method()); a != nil {
+		if refresher, ok := a.(Refresher); ok {
+			ui.Log(ui.RestLogger, "Token for %s expires in %v, refreshing", c.Server, remaining.Round(time.Second))
+
+			var refreshed Credential
+
+			refreshed, err = refresher.Refresh(c)
+			if err == nil {
+				SetCredential(refreshed)
+
+				if err = settings.Save(); err != nil {
+					return c, true, errors.NewError(err)
+				}
+
+				return refreshed, true, nil
+			}
+
+			ui.Log(ui.RestLogger, "Token refresh for %s failed, %v", c.Server, err)
+		}
+	}
+
+	// If the refresh failed but the token is still valid, keep using it
+	// until it expires.
+	if remaining > 0 {
+		return c, true, nil
+	}
+
+	return c, true, errors.ErrExpiredToken.Context(c.Server)
+}
+
+// refreshToken calls the refresh endpoint of the server that issued the
+// credential, and returns a new credential with the replacement token.
+func refreshToken(c Credential) (Credential, error) {
+	url := strings.TrimSuffix(c.Server, "/") + defs.ServicesLogonRefreshPath
+
+	client, err := newRestClient(nil)
+	if err != nil {
+		return c, err
+	}
+
+	client.SetDisableWarn(true)
+
+	req := client.NewRequest().SetAuthToken(c.Token)
+	req.Header.Set("Accept", defs.JSONMediaType)
+	AddAgent(req, defs.LogonAgent)
+
+	r, err := req.Post(url)
+	if err != nil {
+		return c, errors.NewError(err)
+	}
+
+	ui.Log(ui.RestLogger, "REST POST %s; status %d", url, r.StatusCode())
+
+	if r.StatusCode() != http.StatusOK {
+		return c, errors.ErrHTTP.Context(r.StatusCode())
+	}
+
+	payload := defs.LogonResponse{}
+	if err := json.Unmarshal(r.Body(), &payload); err != nil {
+		return c, errors.NewError(err)
+	}
+
+	if payload.Token == "" {
+		return c, errors.ErrInvalidCredentials
+	}
+
+	c.Token = payload.Token
+	c.Expiration = payload.Expiration
+
+	return c, nil
+}
+
+// refreshWindow returns how long before expiration a token is refreshed.
+func refreshWindow() time.Duration {
+	return durationSetting(defs.LogonRefreshWindowSetting, DefaultRefreshWindow)
+}
+
+// readCredentials reads the credential map from the profile. The caller
+// must hold the credentials mutex.
+func readCredentials() map[string]Credential {
+	credentials := map[string]Credential{}
+
+	if text := settings.Get(defs.LogonCredentialsSetting); text != "" {
+		if err := json.Unmarshal([]byte(text), &credentials); err != nil {
+			ui.Log(ui.RestLogger, "Unable to read stored credentials, %v", err)
+		}
+	}
+
+	return credentials
+}
+
+// writeCredentials stores the credential map in the profile. The caller
+// must hold the credentials mutex.
+func writeCredentials(credentials map[string]Credential) {
+	if len(credentials) == 0 {
+		_ = settings.Delete(defs.LogonCredentialsSetting)
+
+		return
+	}
+
+	b, _ := json.Marshal(credentials)
+	settings.Set(defs.LogonCredentialsSetting, string(b))
+}
+
+// normalizeServer converts a server URL to the form used as the key in the
+// credential store.
+func normalizeServer(server string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(server)), "/")
+}
@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+func TestCredentials(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	SetCredential(Credential{Server: "https://dev.example.com/", User: "joe", Token: "dev-token"})
+	SetCredential(Credential{Server: "https://prod.example.com", User: "mary", Token: "prod-token"})
+
+	if c, found := GetCredential("HTTPS://DEV.EXAMPLE.COM"); !found || c.Token != "dev-token" {
+		t.Errorf("GetCredential(dev) = %v, %v", c, found)
+	}
+
+	if c, found := GetCredential("https://prod.example.com/"); !found || c.User != "mary" {
+		t.Errorf("GetCredential(prod) = %v, %v", c, found)
+	}
+
+	if n := len(Credentials()); n != 2 {
+		t.Errorf("Credentials() length = %d, want 2", n)
+	}
+
+	if !DeleteCredential("https://dev.example.com") {
+		t.Errorf("DeleteCredential(dev) did not find credential")
+	}
+
+	if _, found := GetCredential("https://dev.example.com"); found {
+		t.Errorf("GetCredential(dev) found deleted credential")
+	}
+}
+
+func TestActiveTokenRefresh(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	refreshed := time.Now().Add(time.Hour).Format(time.UnixDate)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != defs.ServicesLogonRefreshPath || r.Header.Get("Authorization") != "Bearer old-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		b, _ := json.Marshal(defs.LogonResponse{Token: "new-token", Expiration: refreshed})
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	// A token that expires within the refresh window is replaced.
+	SetCredential(Credential{
+		Server:     server.URL,
+		Token:      "old-token",
+		Expiration: time.Now().Add(time.Minute).Format(time.UnixDate),
+	})
+
+	c, _, err := activeCredential(server.URL, true)
+	if err != nil || c.Token != "new-token" {
+		t.Errorf("activeCredential() = %s, %v; want new-token", c.Token, err)
+	}
+
+	if c, _ := GetCredential(server.URL); c.Expiration != refreshed {
+		t.Errorf("activeCredential() did not store refreshed expiration, got %s", c.Expiration)
+	}
+
+	// An expired token that cannot be refreshed is an error.
+	SetCredential(Credential{
+		Server:     server.URL,
+		Token:      "bad-token",
+		Expiration: time.Now().Add(-time.Minute).Format(time.UnixDate),
+	})
+
+	if _, _, err := activeCredential(server.URL, true); !errors.Equals(err, errors.ErrExpiredToken) {
+		t.Errorf("activeCredential() error = %v, want expired token", err)
+	}
+}
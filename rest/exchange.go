@@ -1,23 +1,18 @@
 package rest
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/tucats/gopackages/app-cli/settings"
 	"github.com/tucats/gopackages/app-cli/ui"
 	"github.com/tucats/gopackages/defs"
 	"github.com/tucats/gopackages/errors"
-	"github.com/tucats/gopackages/expressions/data"
-	"github.com/tucats/gopackages/util"
-	"gopkg.in/resty.v1"
 )
 
 // ServerCertificateFile is the default file name for the server certificate.
@@ -35,6 +30,15 @@ const MaxRedirectCount = 10
 var tlsConfiguration *tls.Config
 var tlsConfigurationMutex sync.Mutex
 
+// tlsVersions maps the names used for the minimum TLS version setting to the
+// version values.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
 // openServices is a list of endpoint paths that do not require the
 // addition of an authorization token.
 var openServices = []string{
@@ -55,226 +59,149 @@ func envDefault(name, defaultValue string) string {
 
 // Exchange is a helper wrapper around a rest call. This is generally used by all the
 // CLI client operations _except_ the logon operation, since at that point the token
-// is not known (or used).
+// is not known (or used). The request is sent using the default client, which
+// sends it to the default logon server unless another client was set using the
+// function SetDefaultClient().
 func Exchange(endpoint, method string, body interface{}, response interface{}, agentType string, mediaTypes ...string) error {
-	var resp *resty.Response
-
-	var err error
-
-	url := settings.Get(defs.LogonServerSetting)
+	return ExchangeWithContext(context.Background(), endpoint, method, body, response, agentType, mediaTypes...)
+}
 
-	if url == "" {
-		url = "http://localhost:8080"
-	}
+// ExchangeWithContext is the same as Exchange, but the request is cancelled if the
+// context is cancelled or its deadline passes. The timeout and retry behavior are
+// taken from the request policy in the context, if one was set using the function
+// WithRequestPolicy(), or from the profile settings.
+func ExchangeWithContext(ctx context.Context, endpoint, method string, body interface{}, response interface{}, agentType string, mediaTypes ...string) error {
+	return DefaultClient().exchange(ctx, endpoint, method, body, response, agentType, mediaTypes...)
+}
 
-	url = strings.TrimSuffix(url, "/") + endpoint
+// ServerExchange is the same as Exchange, but the request is sent to the given server
+// instead of the default logon server. The token used to authenticate the request is
+// the one stored for that server by a previous logon. If the token is about to expire,
+// it is refreshed. If it has already expired, an error is returned.
+func ServerExchange(server, endpoint, method string, body interface{}, response interface{}, agentType string, mediaTypes ...string) error {
+	return ServerExchangeWithContext(context.Background(), server, endpoint, method, body, response, agentType, mediaTypes...)
+}
 
-	ui.Log(ui.RestLogger, "%s %s", strings.ToUpper(method), url)
+// ServerExchangeWithContext is the same as ServerExchange, but uses the context to
+// cancel the request and to find the request policy. The request is sent using a
+// Client that is shared by all requests to the same server, so connections to the
+// server are reused.
+func ServerExchangeWithContext(ctx context.Context, server, endpoint, method string, body interface{}, response interface{}, agentType string, mediaTypes ...string) error {
+	return clientFor(server).exchange(ctx, endpoint, method, body, response, agentType, mediaTypes...)
+}
 
-	client := resty.New().SetRedirectPolicy(resty.FlexibleRedirectPolicy(MaxRedirectCount))
+// GetTLSConfiguration returns the TLS configuration used for REST requests. The
+// configuration is created from the profile settings the first time it is needed,
+// and is then reused. Use ResetTLSConfiguration() if the settings change.
+//
+// If insecure connections are allowed, the server certificate is not verified.
+// Otherwise, the server certificate must be signed by one of the system roots, the
+// certificate authorities in the CA bundle files, or the certificate in the server
+// certificate file if there is one in the current directory or the lib directory of
+// the runtime path. If a client certificate and key are set in the profile, the
+// certificate is presented to the server for mutual TLS authentication.
+func GetTLSConfiguration() (*tls.Config, error) {
+	tlsConfigurationMutex.Lock()
+	defer tlsConfigurationMutex.Unlock()
 
-	// Unless this is a open (un-authenticate) service, let's verify that the
-	// authentication token is still valid.
-	if util.InList(endpoint, openServices...) {
-		ui.Log(ui.RestLogger, "Endpoint %s does not require token", endpoint)
-	} else {
-		if token := settings.Get(defs.LogonTokenSetting); token != "" {
-			// Let's check to see if it's expired already... Note we skip this if the
-			// agent string is "status".
-			if !strings.EqualFold(agentType, defs.StatusAgent) {
-				if expirationString := settings.Get(defs.LogonTokenExpirationSetting); expirationString != "" {
-					expireTime, err := time.Parse(time.UnixDate, expirationString)
-					if err != nil {
-						return errors.NewError(err)
-					}
-
-					now := time.Since(expireTime)
-					if now > 0 {
-						ui.Say("Your login has expired. Use the ego logon command to login again to %s", settings.Get(defs.LogonServerSetting))
-
-						os.Exit(1)
-					}
-				}
-			}
-
-			client.SetAuthToken(token)
-			ui.Log(ui.RestLogger, "Authorization set using bearer token: %s...", token[:4])
-		}
+	if tlsConfiguration != nil {
+		return tlsConfiguration, nil
 	}
 
-	if config, err := GetTLSConfiguration(); err != nil {
-		return err
-	} else {
-		client.SetTLSClientConfig(config)
+	config := &tls.Config{
+		ServerName: settings.Get(defs.TLSServerNameSetting),
 	}
 
-	r := client.NewRequest()
-
-	// Lets figure out what media types we're sending and reciving. By default, they
-	// are anonymous JSON. But if the call included one or two strings, they are used
-	// as the receiving and sending media types respectively.
-	receiveMediaType := defs.JSONMediaType
-	sendMediaType := defs.JSONMediaType
-
-	if len(mediaTypes) > 0 {
-		receiveMediaType = mediaTypes[0]
+	if version := settings.Get(defs.TLSMinVersionSetting); version != "" {
+		v, found := tlsVersions[strings.TrimPrefix(strings.ToLower(version), "tls")]
+		if !found {
+			return nil, errors.ErrInvalidValue.Context(defs.TLSMinVersionSetting + "=" + version)
+		}
 
-		ui.Log(ui.RestLogger, "Adding media type: %s", receiveMediaType)
+		config.MinVersion = v
 	}
 
-	if len(mediaTypes) > 1 {
-		sendMediaType = mediaTypes[1]
+	// If insecure is specified, then skip verification for TLS
+	if allowInsecure || os.Getenv("APP_INSECURE_CLIENT") == defs.True {
+		config.InsecureSkipVerify = true
 
-		ui.Log(ui.RestLogger, "Adding media type: %s", sendMediaType)
+		ui.Log(ui.RestLogger, "Client TLS skipping server verification")
+	} else if roots, err := rootCertificates(); err != nil {
+		return nil, err
+	} else {
+		config.RootCAs = roots
 	}
 
-	r.Header.Add("Content-Type", sendMediaType)
-	r.Header.Add("Accept", receiveMediaType)
-	AddAgent(r, agentType)
-
-	if body != nil {
-		b, err := json.MarshalIndent(body, "", "  ")
+	if settings.Get(defs.ClientCertificateFileSetting) != "" {
+		cert, err := clientCertificate()
 		if err != nil {
-			return errors.NewError(err)
+			return nil, err
 		}
 
-		ui.Log(ui.RestLogger, "Request payload:\n%s", string(b))
+		config.Certificates = []tls.Certificate{cert}
 
-		r.SetBody(b)
+		ui.Log(ui.RestLogger, "Client TLS using client certificate %s", settings.Get(defs.ClientCertificateFileSetting))
 	}
 
-	resp, err = r.Execute(method, url)
-	if err != nil {
-		ui.Log(ui.RestLogger, "REST failed, %v", err)
-
-		return errors.NewError(err)
-	}
+	tlsConfiguration = config
 
-	if err == nil {
-		status := resp.StatusCode()
-
-		ui.Log(ui.RestLogger, "Status: %d", status)
-
-		if err == nil && status != http.StatusOK && response == nil {
-			return errors.ErrHTTP.Context(status)
-		}
-
-		if replyMedia := resp.Header().Get("Content-Type"); replyMedia != "" {
-			ui.Log(ui.RestLogger, "Reply media type: %s", replyMedia)
-		}
+	return tlsConfiguration, nil
+}
 
-		// If there was an error, and the runtime rest automatic error handling is enabled,
-		// try to find the message text in the response, and if found, form an error response
-		// to the local caller using that text.
-		if status < 200 || status > 299 {
-			errorResponse := map[string]interface{}{}
+// ResetTLSConfiguration discards the TLS configuration, so it is created again
+// from the profile settings the next time it is needed. Clients that have
+// already made a request keep the configuration they were using.
+func ResetTLSConfiguration() {
+	tlsConfigurationMutex.Lock()
+	defer tlsConfigurationMutex.Unlock()
 
-			err := json.Unmarshal(resp.Body(), &errorResponse)
-			if err == nil {
-				if msg, found := errorResponse["msg"]; found {
-					ui.Log(ui.RestLogger, "Response payload:\n%v", string(resp.Body()))
+	tlsConfiguration = nil
+}
 
-					return errors.NewMessage(data.String(msg))
-				}
+// rootCertificates returns the certificate authorities used to verify the
+// server certificate. This is the system roots, plus the certificates in the
+// CA bundle files and the server certificate file.
+func rootCertificates() (*x509.CertPool, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil || roots == nil {
+		roots = x509.NewCertPool()
+	}
 
-				if msg, found := errorResponse["message"]; found {
-					ui.Log(ui.RestLogger, "Response payload:\n%v", string(resp.Body()))
+	files := []string{}
 
-					return errors.NewMessage(data.String(msg))
-				}
-			}
+	for _, file := range strings.Split(settings.Get(defs.TLSCABundleSetting), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
 		}
+	}
 
-		if err == nil && response != nil {
-			body := string(resp.Body())
-			if body != "" {
-				if !util.InList(body[0:1], "{", "[", "\"") {
-					r := defs.RestStatusResponse{
-						Status:  resp.StatusCode(),
-						Message: strings.TrimSuffix(body, "\n"),
-					}
-					b, _ := json.Marshal(r)
-					body = string(b)
-				}
-
-				err = json.Unmarshal([]byte(body), response)
-				if err == nil && ui.IsActive(ui.RestLogger) {
-					responseBytes, _ := json.MarshalIndent(response, "", "  ")
-
-					ui.Log(ui.RestLogger, "Response payload:\n%s", string(responseBytes))
-				}
-
-				if err == nil && status != http.StatusOK {
-					if m, ok := response.(map[string]interface{}); ok {
-						if msg, ok := m["Message"]; ok {
-							err = errors.NewMessage(data.String(msg))
-						}
-					}
-				}
-			}
-		}
+	// Is there a server cert file we can/should be using? It is optional, since
+	// the server may have a certificate signed by one of the other roots.
+	filename := ServerCertificateFile
+	if _, err := os.Stat(filename); err != nil {
+		filename = filepath.Join(settings.Get(defs.PathSetting), defs.LibPathName, ServerCertificateFile)
 	}
 
-	if err != nil {
-		err = errors.NewError(err)
+	if _, err := os.Stat(filename); err == nil {
+		files = append(files, filename)
 	}
 
-	return err
-}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			ui.Log(ui.RestLogger, "Failed to read certificate file: %v", err)
 
-func GetTLSConfiguration() (*tls.Config, error) {
-	// If we haven't ever set up the TLS configuration, let's do so now. This is a serialized
-	// operation, so for most use cases, the existing TLS configuration is used. For the first
-	// case, we will either set up an insecure TLS (not recommended) or will use the CRT and
-	// KEY files found in the EGO PATH if present. If no cert files found, then it assumes it
-	// should just use the native certs.
-	tlsConfigurationMutex.Lock()
+			return nil, errors.NewError(err)
+		}
+
+		if !roots.AppendCertsFromPEM(b) {
+			ui.Log(ui.RestLogger, "Failed to parse root certificate for client configuration")
 
-	if tlsConfiguration == nil {
-		kind := "using certificate file"
-
-		// If insecure is specified, then skip verification for TLS
-		if allowInsecure || os.Getenv("APP_INSECURE_CLIENT") == defs.True {
-			tlsConfiguration = &tls.Config{InsecureSkipVerify: true}
-			kind = "skipping server verification"
-		} else {
-			// Is there a server cert file we can/should be using?
-			var err error
-
-			var b []byte
-
-			filename := ServerCertificateFile
-
-			b, err = os.ReadFile(filename)
-			if err != nil {
-				path := filepath.Join(settings.Get(defs.PathSetting), defs.LibPathName)
-				filename = filepath.Join(path, ServerCertificateFile)
-				b, err = os.ReadFile(filename)
-			}
-
-			if err == nil {
-				kind = kind + " " + filename
-				roots := x509.NewCertPool()
-
-				ok := roots.AppendCertsFromPEM(b)
-				if !ok {
-					ui.Log(ui.RestLogger, "Failed to parse root certificate for client configuration")
-
-					return nil, errors.ErrCertificateParseError.Context(filename)
-				} else {
-					tlsConfiguration = &tls.Config{RootCAs: roots}
-				}
-			} else {
-				ui.Log(ui.RestLogger, "Failed to read server certificate file: %v", err)
-
-				return nil, errors.NewError(err)
-			}
+			return nil, errors.ErrCertificateParseError.Context(file)
 		}
 
-		ui.Log(ui.RestLogger, "Client TLS %s", kind)
+		ui.Log(ui.RestLogger, "Client TLS using certificate file %s", file)
 	}
 
-	tlsConfigurationMutex.Unlock()
-
-	return tlsConfiguration, nil
+	return roots, nil
 }
@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"strconv"
+
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/i18n"
+	"gopkg.in/resty.v1"
+)
+
+// HTTPError is the error returned when a REST request receives a response
+// with a status that is not in the 2xx range. Use StatusCode() to find the
+// status of an error returned from Exchange(), or errors.As() to get the
+// full HTTPError.
+type HTTPError struct {
+	// The method and URL of the request.
+	Method string
+	URL    string
+
+	// The HTTP status code of the response.
+	Status int
+
+	// The response headers.
+	Header http.Header
+
+	// The response body, decoded as a status response. If the body is not
+	// a status response, only the status is set.
+	Response defs.RestStatusResponse
+
+	// The raw response body.
+	Body []byte
+
+	// The error reported by the server in the status response, if there
+	// was one.
+	Cause *errors.Error
+}
+
+// newHTTPError creates an HTTPError from a response and its body.
+func newHTTPError(resp *resty.Response, body []byte) *HTTPError {
+	e := &HTTPError{
+		Status: resp.StatusCode(),
+		Header: resp.Header(),
+		Body:   body,
+	}
+
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL
+	}
+
+	// Ego servers return the message in the "msg" field, but other servers
+	// often use "message".
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(e.Body, &fields); err == nil {
+		_ = json.Unmarshal(e.Body, &e.Response)
+
+		if e.Response.Message == "" {
+			if msg, ok := fields["message"].(string); ok {
+				e.Response.Message = msg
+			}
+		}
+	}
+
+	e.Response.Status = e.Status
+
+	if len(e.Response.Error) > 0 {
+		cause := &errors.Error{}
+		if err := json.Unmarshal(e.Response.Error, cause); err == nil {
+			e.Cause = cause
+		}
+	}
+
+	return e
+}
+
+// Error formats the status and the message from the response, if there was
+// one.
+func (e *HTTPError) Error() string {
+	text := i18n.E("http") + " " + strconv.Itoa(e.Status)
+
+	if statusText := http.StatusText(e.Status); statusText != "" {
+		text = text + " " + statusText
+	}
+
+	if e.Response.Message != "" {
+		text = text + ": " + e.Response.Message
+	}
+
+	return text
+}
+
+// Unwrap returns errors.ErrHTTP, so an HTTPError is also an ErrHTTP error.
+func (e *HTTPError) Unwrap() error {
+	return errors.ErrHTTP
+}
+
+// Is reports whether the target is an HTTPError with the same status. This
+// allows an error to be compared to a value such as &HTTPError{Status: 404}.
+// It also reports whether the error the server reported is the target, so
+// the error can be compared to a predefined error such as ErrNoSuchUser.
+func (e *HTTPError) Is(target error) bool {
+	if t, ok := target.(*HTTPError); ok {
+		return t.Status == e.Status
+	}
+
+	return e.Cause != nil && e.Cause.Is(target)
+}
+
+// Code returns the code of the error the server reported, or the code of
+// ErrHTTP if it did not report one.
+func (e *HTTPError) Code() string {
+	if code := e.Cause.Code(); code != "" {
+		return code
+	}
+
+	return errors.ErrHTTP.Code()
+}
+
+// StatusCode returns the HTTP status of the response.
+func (e *HTTPError) StatusCode() int {
+	return e.Status
+}
+
+// StatusCode returns the HTTP status of the response that caused the error.
+// If the error was not caused by an HTTP response, zero is returned.
+func StatusCode(err error) int {
+	var e *HTTPError
+
+	if goerrors.As(err, &e) {
+		return e.Status
+	}
+
+	return 0
+}
@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+func TestHTTPError(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	// The server returns the status named in the path, with a message.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+
+		w.Header().Set("X-Request-ID", "abc")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"status":` + strconv.Itoa(status) + `,"message":"status ` + strconv.Itoa(status) + `"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+	}{
+		{name: "not found", status: http.StatusNotFound, want: "received HTTP 404 Not Found: status 404"},
+		{name: "conflict", status: http.StatusConflict, want: "received HTTP 409 Conflict: status 409"},
+		{name: "unauthorized", status: http.StatusUnauthorized, want: "received HTTP 401 Unauthorized: status 401"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.Exchange("/"+strconv.Itoa(tt.status), http.MethodGet, nil, nil)
+
+			if got := StatusCode(err); got != tt.status {
+				t.Errorf("StatusCode() = %d, want %d", got, tt.status)
+			}
+
+			if !goerrors.Is(err, &HTTPError{Status: tt.status}) {
+				t.Errorf("errors.Is() did not match status %d", tt.status)
+			}
+
+			if !errors.Equals(err, errors.ErrHTTP) {
+				t.Errorf("errors.Equals() did not match ErrHTTP")
+			}
+
+			var e *HTTPError
+			if !goerrors.As(err, &e) {
+				t.Fatalf("errors.As() did not find HTTPError in %v", err)
+			}
+
+			if e.Method != http.MethodGet || e.Header.Get("X-Request-ID") != "abc" || e.Response.Message == "" {
+				t.Errorf("HTTPError = %+v", e)
+			}
+
+			if err.Error() != tt.want {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorCause(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	// The server reports an error with a code, as rest/server does.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cause := errors.ErrInvalidLogLevel.With("logger", "server").Context("loud")
+		response := defs.RestStatusResponse{Status: http.StatusBadRequest, Message: cause.Error()}
+		response.Error, _ = json.Marshal(cause)
+
+		b, _ := json.Marshal(response)
+
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL).Exchange("/", http.MethodGet, nil, nil)
+
+	if !goerrors.Is(err, errors.ErrInvalidLogLevel) || goerrors.Is(err, errors.ErrInvalidLogSink) {
+		t.Errorf("errors.Is() did not match the error reported by the server: %v", err)
+	}
+
+	if code := errors.Code(err); code != "log.level" {
+		t.Errorf("errors.Code() = %q, want %q", code, "log.level")
+	}
+
+	var e *HTTPError
+	if !goerrors.As(err, &e) || e.Cause == nil || e.Cause.Fields()["logger"] != "server" {
+		t.Errorf("HTTPError cause was not read: %+v", e)
+	}
+}
@@ -0,0 +1,214 @@
+package rest
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/i18n"
+	"gopkg.in/resty.v1"
+)
+
+// The grant type used to poll the token endpoint during the OAuth2 device
+// authorization flow (RFC 8628).
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// The default polling interval for the device flow, if the authorization
+// server does not specify one.
+const defaultDevicePollInterval = 5 * time.Second
+
+// oauth2Authenticator implements the OAuth2 device authorization flow. The
+// user is shown a URL and a code to enter in a browser, and the token is
+// retrieved once the user has approved the request. The authorization server
+// endpoints and client ID are read from the profile.
+type oauth2Authenticator struct{}
+
+// deviceAuthorization is the response from the device authorization endpoint.
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// tokenResponse is the response from the OAuth2 token endpoint.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+	Description  string `json:"error_description"`
+}
+
+func (a *oauth2Authenticator) Logon(server, user, secret string) (Credential, error) {
+	deviceURL := settings.Get(defs.OAuthDeviceURLSetting)
+	tokenURL := settings.Get(defs.OAuthTokenURLSetting)
+	clientID := settings.Get(defs.OAuthClientIDSetting)
+
+	if deviceURL == "" || tokenURL == "" || clientID == "" {
+		return Credential{}, errors.ErrOAuthNotConfigured
+	}
+
+	fields := map[string]string{"client_id": clientID}
+	if scope := settings.Get(defs.OAuthScopeSetting); scope != "" {
+		fields["scope"] = scope
+	}
+
+	device := deviceAuthorization{}
+	if err := postForm(deviceURL, fields, &device); err != nil {
+		return Credential{}, err
+	}
+
+	if device.DeviceCode == "" {
+		return Credential{}, errors.ErrInvalidCredentials.Context(deviceURL)
+	}
+
+	verificationURL := device.VerificationURIComplete
+	if verificationURL == "" {
+		verificationURL = device.VerificationURI
+	}
+
+	ui.Say("%s", i18n.M("logon.device", map[string]interface{}{
+		"url":  verificationURL,
+		"code": device.UserCode,
+	}))
+
+	interval := time.Duration(device.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+
+	deadline := time.Now().Add(time.Duration(device.ExpiresIn) * time.Second)
+
+	// Poll the token endpoint until the user approves or denies the request,
+	// or the device code expires.
+	for device.ExpiresIn <= 0 || time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		token := tokenResponse{}
+		if err := postForm(tokenURL, map[string]string{
+			"grant_type":  deviceCodeGrantType,
+			"device_code": device.DeviceCode,
+			"client_id":   clientID,
+		}, &token); err != nil {
+			return Credential{}, err
+		}
+
+		switch token.Error {
+		case "":
+			return token.credential(server, user, ""), nil
+
+		case "authorization_pending":
+			continue
+
+		case "slow_down":
+			interval += defaultDevicePollInterval
+
+		case "access_denied":
+			return Credential{}, errors.ErrInvalidCredentials.Context(token.Description)
+
+		default:
+			return Credential{}, errors.ErrExpiredToken.Context(token.Error)
+		}
+	}
+
+	return Credential{}, errors.ErrExpiredToken.Context(device.UserCode)
+}
+
+func (a *oauth2Authenticator) Authenticate(c Credential, client *resty.Client, request *resty.Request) error {
+	if c.Token != "" {
+		request.SetAuthToken(c.Token)
+		ui.Log(ui.RestLogger, "Authorization set using OAuth2 bearer token")
+	}
+
+	return nil
+}
+
+// Refresh uses the OAuth2 refresh token grant to replace an access token
+// that is about to expire.
+func (a *oauth2Authenticator) Refresh(c Credential) (Credential, error) {
+	if c.RefreshToken == "" {
+		return c, errors.ErrExpiredToken.Context(c.Server)
+	}
+
+	token := tokenResponse{}
+	if err := postForm(settings.Get(defs.OAuthTokenURLSetting), map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": c.RefreshToken,
+		"client_id":     settings.Get(defs.OAuthClientIDSetting),
+	}, &token); err != nil {
+		return c, err
+	}
+
+	if token.Error != "" {
+		return c, errors.ErrExpiredToken.Context(token.Error)
+	}
+
+	// The server may not issue a new refresh token, in which case the
+	// existing one remains valid.
+	return token.credential(c.Server, c.User, c.RefreshToken), nil
+}
+
+// credential converts a token response into a credential for the server.
+func (t tokenResponse) credential(server, user, refreshToken string) Credential {
+	c := Credential{
+		Server:       server,
+		User:         user,
+		Token:        t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		Method:       OAuth2Authentication,
+	}
+
+	if c.RefreshToken == "" {
+		c.RefreshToken = refreshToken
+	}
+
+	if t.ExpiresIn > 0 {
+		c.Expiration = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second).Format(time.UnixDate)
+	}
+
+	return c
+}
+
+// postForm posts form-encoded fields to an OAuth2 endpoint and decodes the
+// JSON response. Error responses from the token endpoint are also decoded,
+// since they carry the state of the device flow.
+func postForm(url string, fields map[string]string, response interface{}) error {
+	client, err := newRestClient(nil)
+	if err != nil {
+		return err
+	}
+
+	client.SetDisableWarn(true)
+
+	req := client.NewRequest().SetFormData(fields)
+	req.Header.Set("Accept", defs.JSONMediaType)
+	AddAgent(req, defs.LogonAgent)
+
+	r, err := req.Post(url)
+	if err != nil {
+		return errors.NewError(err)
+	}
+
+	ui.Log(ui.RestLogger, "REST POST %s; status %d", url, r.StatusCode())
+
+	if err := json.Unmarshal(r.Body(), response); err != nil {
+		if r.StatusCode() < 200 || r.StatusCode() > 299 {
+			return errors.ErrHTTP.Context(r.StatusCode())
+		}
+
+		return errors.NewError(err)
+	}
+
+	if r.StatusCode() >= 500 {
+		return errors.ErrHTTP.Context(strings.TrimSpace(r.Status()))
+	}
+
+	return nil
+}
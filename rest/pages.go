@@ -0,0 +1,309 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tucats/gopackages/app-cli/tables"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+// DefaultPageSize is the number of items requested in each page, if the page
+// size is not given.
+const DefaultPageSize = 100
+
+// itemKeys are the names of the fields in a collection response that can hold
+// the items of a page, in the order they are checked.
+var itemKeys = []string{"items", "rows"}
+
+// Pager reads a REST collection one page at a time. Each page is requested
+// using the start and limit parameters. If the server returns a Link header
+// with a "next" relation, that link is followed instead.
+//
+// A Pager is used like this:
+//
+//	p := rest.Paginate(defs.AdminUsersPath, 0)
+//	for p.Next() {
+//	    for _, item := range p.Page() {
+//	        ...
+//	    }
+//	}
+//	if err := p.Err(); err != nil {
+//	    ...
+//	}
+type Pager struct {
+	client     *Client
+	ctx        context.Context
+	endpoint   string
+	mediaTypes []string
+	pageSize   int
+	start      int
+	next       string
+	page       []map[string]interface{}
+	done       bool
+	err        error
+}
+
+// Paginate returns a Pager that reads the collection at the endpoint using
+// the default client. If the page size is zero, DefaultPageSize is used. The
+// optional media type is the media type of the pages.
+func Paginate(endpoint string, pageSize int, mediaTypes ...string) *Pager {
+	return DefaultClient().Paginate(endpoint, pageSize, mediaTypes...)
+}
+
+// Paginate returns a Pager that reads the collection at the endpoint. If the
+// page size is zero, DefaultPageSize is used. The optional media type is the
+// media type of the pages.
+func (c *Client) Paginate(endpoint string, pageSize int, mediaTypes ...string) *Pager {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	return &Pager{
+		client:     c,
+		ctx:        context.Background(),
+		endpoint:   endpoint,
+		mediaTypes: mediaTypes,
+		pageSize:   pageSize,
+	}
+}
+
+// SetContext sets the context used for the page requests.
+func (p *Pager) SetContext(ctx context.Context) *Pager {
+	p.ctx = ctx
+
+	return p
+}
+
+// Next reads the next page of the collection. It returns false when there are
+// no more pages, or if an error occurred. Use Err() to check for an error.
+func (p *Pager) Next() bool {
+	if p.done {
+		return false
+	}
+
+	endpoint := p.next
+	if endpoint == "" {
+		endpoint, p.err = pageEndpoint(p.endpoint, p.start, p.pageSize)
+		if p.err != nil {
+			p.done = true
+
+			return false
+		}
+	}
+
+	resp, err := p.client.send(p.ctx, request{
+		endpoint:   endpoint,
+		method:     http.MethodGet,
+		agent:      p.client.agent,
+		mediaTypes: p.mediaTypes,
+	})
+	if err != nil {
+		p.err = err
+		p.done = true
+
+		return false
+	}
+
+	payload := map[string]interface{}{}
+	if err := p.client.response(resp, &payload); err != nil {
+		p.err = err
+		p.done = true
+
+		return false
+	}
+
+	p.page = pageItems(payload)
+	p.start += len(p.page)
+
+	// If the server said where the next page is, use that. Otherwise, a short
+	// page is the last one. A link to a different server is an error, since
+	// the request would be sent, with its credentials, to the wrong server.
+	if p.next, p.err = p.client.nextLink(resp.Header().Get("Link")); p.err != nil {
+		p.done = true
+	} else if p.next == "" {
+		p.done = len(p.page) < p.pageSize
+	}
+
+	ui.Log(p.client.logger, "Read page of %d items", len(p.page))
+
+	return len(p.page) > 0
+}
+
+// Page returns the items of the current page.
+func (p *Pager) Page() []map[string]interface{} {
+	return p.page
+}
+
+// Err returns the error that stopped the Pager, if any.
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// Each calls the function for every item in the collection. If the function
+// returns an error, no more pages are read and the error is returned.
+func (p *Pager) Each(fn func(item map[string]interface{}) error) error {
+	for p.Next() {
+		for _, item := range p.page {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return p.err
+}
+
+// All reads every page of the collection, and returns all the items.
+func (p *Pager) All() ([]map[string]interface{}, error) {
+	result := []map[string]interface{}{}
+
+	err := p.Each(func(item map[string]interface{}) error {
+		result = append(result, item)
+
+		return nil
+	})
+
+	return result, err
+}
+
+// Table adds a row to the table for every item in the collection. The columns
+// name the item fields used for each column of the table; if none are given,
+// the table headings are used. A field missing from an item is shown as an
+// empty value.
+func (p *Pager) Table(t *tables.Table, columns ...string) error {
+	if len(columns) == 0 {
+		columns = t.GetHeadings()
+	}
+
+	return p.Each(func(item map[string]interface{}) error {
+		row := make([]interface{}, len(columns))
+
+		for n, column := range columns {
+			if value, found := item[column]; found && value != nil {
+				row[n] = value
+			} else {
+				row[n] = ""
+			}
+		}
+
+		return t.AddRowItems(row...)
+	})
+}
+
+// pageEndpoint adds the start and limit parameters to the endpoint, keeping
+// any other parameters it already has.
+func pageEndpoint(endpoint string, start, limit int) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", errors.NewError(err)
+	}
+
+	query := u.Query()
+	query.Set(defs.StartParameterName, strconv.Itoa(start))
+	query.Set(defs.LimitParameterName, strconv.Itoa(limit))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// pageItems returns the items from a page. An abstract row set, where each
+// row is an array of values, is converted using the column names.
+func pageItems(payload map[string]interface{}) []map[string]interface{} {
+	var list []interface{}
+
+	for _, key := range itemKeys {
+		if items, ok := payload[key].([]interface{}); ok {
+			list = items
+
+			break
+		}
+	}
+
+	columns, _ := payload["columns"].([]interface{})
+	result := make([]map[string]interface{}, 0, len(list))
+
+	for _, item := range list {
+		switch actual := item.(type) {
+		case map[string]interface{}:
+			result = append(result, actual)
+
+		case []interface{}:
+			row := map[string]interface{}{}
+
+			for n, value := range actual {
+				if n < len(columns) {
+					if name, ok := columns[n].(string); ok {
+						row[name] = value
+					}
+				}
+			}
+
+			result = append(result, row)
+		}
+	}
+
+	return result
+}
+
+// nextLink returns the endpoint of the "next" relation from a Link header,
+// relative to the base URL of the client. If there is no such relation, an
+// empty string is returned.
+func (c *Client) nextLink(header string) (string, error) {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+
+		for _, param := range parts[1:] {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || !strings.EqualFold(name, "rel") {
+				continue
+			}
+
+			for _, rel := range strings.Fields(strings.Trim(value, "\"")) {
+				if strings.EqualFold(rel, "next") {
+					return c.relativeEndpoint(target)
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// relativeEndpoint converts a link to an endpoint on the client's server. The
+// link is resolved against the base URL of the client, so it can be relative,
+// such as "page2" or "/v1/items?start=3". An error is returned if the resolved
+// link is to a different server, as determined by the scheme, host and port,
+// if it includes user information, or if it is not under the path of the base
+// URL, since the endpoint is appended to the base URL when it is sent.
+func (c *Client) relativeEndpoint(link string) (string, error) {
+	base, err := url.Parse(c.baseURL + "/")
+	if err != nil {
+		return "", errors.ErrInvalidPageLink.Context(link)
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", errors.ErrInvalidPageLink.Context(link)
+	}
+
+	u = base.ResolveReference(u)
+	if u.User != nil || !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) {
+		return "", errors.ErrInvalidPageLink.Context(link)
+	}
+
+	endpoint := u.RequestURI()
+	basePath := strings.TrimSuffix(base.EscapedPath(), "/")
+
+	if !strings.HasPrefix(endpoint, basePath+"/") {
+		return "", errors.ErrInvalidPageLink.Context(link)
+	}
+
+	return strings.TrimPrefix(endpoint, basePath), nil
+}
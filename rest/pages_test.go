@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/tables"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+)
+
+func TestPaginate(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	names := []string{"a", "b", "c", "d", "e"}
+
+	// The server returns a page of items using the start and limit parameters.
+	// The "/linked" endpoint also returns a Link header for the next page.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get(defs.StartParameterName))
+		limit, _ := strconv.Atoi(r.URL.Query().Get(defs.LimitParameterName))
+
+		if r.URL.Path == "/linked" {
+			limit = 3
+			if start+limit < len(names) {
+				w.Header().Set("Link", fmt.Sprintf(`</linked?start=%d>; rel="next"`, start+limit))
+			}
+		}
+
+		items := []map[string]interface{}{}
+		for n := start; n < start+limit && n < len(names); n++ {
+			items = append(items, map[string]interface{}{"name": names[n], "id": n})
+		}
+
+		b, _ := json.Marshal(map[string]interface{}{"items": items, "count": len(items)})
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	tests := []struct {
+		name     string
+		endpoint string
+		pageSize int
+		pages    int
+	}{
+		{name: "start and limit", endpoint: "/items", pageSize: 2, pages: 3},
+		{name: "exact pages", endpoint: "/items", pageSize: 5, pages: 1},
+		{name: "link header", endpoint: "/linked", pageSize: 10, pages: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := client.Paginate(tt.endpoint, tt.pageSize)
+			pages := 0
+			items := []string{}
+
+			for p.Next() {
+				pages++
+
+				for _, item := range p.Page() {
+					items = append(items, item["name"].(string))
+				}
+			}
+
+			if err := p.Err(); err != nil {
+				t.Fatalf("Next() unexpected error %v", err)
+			}
+
+			if fmt.Sprint(items) != fmt.Sprint(names) || pages != tt.pages {
+				t.Errorf("Paginate() read %v in %d pages, want %v in %d pages", items, pages, names, tt.pages)
+			}
+		})
+	}
+
+	table, _ := tables.New([]string{"name", "id"})
+	if err := client.Paginate("/items", 2).Table(table); err != nil {
+		t.Fatalf("Table() unexpected error %v", err)
+	}
+
+	if text, _ := table.String("json"); text != `[{"name":"a","id":0},{"name":"b","id":1},{"name":"c","id":2},{"name":"d","id":3},{"name":"e","id":4}]` {
+		t.Errorf("Table() = %s", text)
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	client := NewClient("https://api.example.com/v1")
+
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{name: "no link", header: "", want: ""},
+		{name: "relative", header: `<items?start=3>; rel="next"`, want: "/items?start=3"},
+		{name: "absolute path", header: `</v1/items?start=3>; rel="next"`, want: "/items?start=3"},
+		{name: "base URL", header: `<https://api.example.com/v1/items?start=3>; rel="next"`, want: "/items?start=3"},
+		{name: "same server", header: `<HTTPS://API.example.com/v1/items?start=3>; rel="next"`, want: "/items?start=3"},
+		{name: "other relation", header: `<https://evil.example.com/items>; rel="prev"`, want: ""},
+		{name: "other host", header: `<https://evil.example.com/v1/items?start=3>; rel="next"`, wantErr: true},
+		{name: "other scheme", header: `<http://api.example.com/v1/items?start=3>; rel="next"`, wantErr: true},
+		{name: "scheme relative", header: `<//evil.example.com/items>; rel="next"`, wantErr: true},
+		{name: "host suffix", header: `<https://api.example.com.evil.net/v1/items>; rel="next"`, wantErr: true},
+		{name: "user info", header: `<https://api.example.com@evil.net/v1/items>; rel="next"`, wantErr: true},
+		{name: "other port", header: `<https://api.example.com:9999/v1/items>; rel="next"`, wantErr: true},
+		{name: "outside base path", header: `</v2/items>; rel="next"`, wantErr: true},
+		{name: "base path prefix", header: `</v10/items>; rel="next"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.nextLink(tt.header)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("nextLink() = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+
+			if err != nil && !errors.ErrInvalidPageLink.Is(err) {
+				t.Errorf("nextLink() error = %v, want %v", err, errors.ErrInvalidPageLink)
+			}
+		})
+	}
+
+	// A relative link from a server without a base path is resolved from the root.
+	root := NewClient("http://api.example.com")
+
+	for link, want := range map[string]string{
+		"page2":                          "/page2",
+		"/items?start=3":                 "/items?start=3",
+		"http://api.example.com/p2":      "/p2",
+		"http://api.example.com.evil/p2": "",
+		"http://api.example.com@evil/p2": "",
+		"http://api.example.com:9999/p2": "",
+	} {
+		got, err := root.relativeEndpoint(link)
+		if got != want || (err != nil) != (want == "") {
+			t.Errorf("relativeEndpoint(%q) = %q, %v; want %q", link, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package rest
+
+import (
+	"context"
+	goerrors "errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"gopkg.in/resty.v1"
+)
+
+// Default values for the request policy, used when the corresponding profile
+// setting is not present.
+const (
+	DefaultTimeout       = 60 * time.Second
+	DefaultRetryCount    = 3
+	DefaultRetryDelay    = 500 * time.Millisecond
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// RequestPolicy describes how long a REST request may take, and how it is
+// retried if it fails with a transient error.
+type RequestPolicy struct {
+	// Timeout is the maximum time allowed for each attempt to send the
+	// request and read the response. If zero, there is no timeout.
+	Timeout time.Duration
+
+	// Retries is the maximum number of times a failed request is retried.
+	// Only idempotent methods are retried.
+	Retries int
+
+	// Delay is the base delay before the first retry. The delay doubles
+	// for each subsequent retry, and a random jitter is applied.
+	Delay time.Duration
+
+	// MaxDelay is the maximum delay between retries. If the server asks for
+	// a longer delay with a Retry-After header, the request is not retried.
+	MaxDelay time.Duration
+}
+
+// retryableStatuses are the HTTP status codes that indicate a transient
+// condition, where the same request may succeed if retried.
+var retryableStatuses = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// idempotentMethods are the HTTP methods that can safely be retried.
+var idempotentMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodTrace,
+}
+
+// policyKey is the context key used to store a request policy.
+type policyKey struct{}
+
+// DefaultRequestPolicy returns the request policy defined by the profile
+// settings, using the default values for any settings that are not present.
+func DefaultRequestPolicy() RequestPolicy {
+	policy := RequestPolicy{
+		Timeout:  durationSetting(defs.RestTimeoutSetting, DefaultTimeout),
+		Retries:  DefaultRetryCount,
+		Delay:    durationSetting(defs.RestRetryDelaySetting, DefaultRetryDelay),
+		MaxDelay: durationSetting(defs.RestRetryMaxDelaySetting, DefaultRetryMaxDelay),
+	}
+
+	if settings.Exists(defs.RestRetryCountSetting) {
+		policy.Retries = settings.GetInt(defs.RestRetryCountSetting)
+	}
+
+	return policy
+}
+
+// WithRequestPolicy returns a copy of the context that carries the given
+// request policy. A REST call made with the resulting context uses this
+// policy instead of the default policy from the profile.
+func WithRequestPolicy(ctx context.Context, policy RequestPolicy) context.Context {
+	return context.WithValue(ctx, policyKey{}, policy)
+}
+
+// requestPolicy returns the policy stored in the context, or the default
+// policy if the context does not have one.
+func requestPolicy(ctx context.Context) RequestPolicy {
+	if policy, ok := ctx.Value(policyKey{}).(RequestPolicy); ok {
+		return policy
+	}
+
+	return DefaultRequestPolicy()
+}
+
+// retryDelay determines if a request should be retried, given the result of
+// the last attempt. If it should be retried, the delay before the next
+// attempt is returned, along with true.
+func (p RequestPolicy) retryDelay(method string, attempt int, resp *resty.Response, err error) (time.Duration, bool) {
+	if attempt >= p.Retries || !isIdempotent(method) {
+		return 0, false
+	}
+
+	// A request that has no recorded response will not have one if it is
+	// retried.
+	if err != nil && goerrors.Is(err, errors.ErrRestNoRecording) {
+		return 0, false
+	}
+
+	if err == nil {
+		if resp == nil || !isRetryableStatus(resp.StatusCode()) {
+			return 0, false
+		}
+
+		// If the server said how long to wait, use that instead of the
+		// backoff delay. If that is longer than the maximum delay, give
+		// up rather than retry before the server is ready.
+		if delay, ok := retryAfter(resp.Header().Get("Retry-After")); ok {
+			if delay > p.MaxDelay {
+				return 0, false
+			}
+
+			return delay, true
+		}
+	}
+
+	return p.backoff(attempt), true
+}
+
+// backoff calculates the delay before the given retry attempt, using
+// exponential backoff with "full jitter". The delay is a random value
+// between zero and the base delay doubled for each prior attempt.
+func (p RequestPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.Delay << uint(attempt)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// retryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if when, err := http.ParseTime(value); err == nil {
+		delay := time.Until(when)
+		if delay < 0 {
+			delay = 0
+		}
+
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// sleepContext waits for the given delay, or until the context is done. The
+// context error is returned if the wait was cut short.
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isIdempotent(method string) bool {
+	method = strings.ToUpper(method)
+
+	for _, m := range idempotentMethods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	for _, s := range retryableStatuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// durationSetting reads a duration from the profile. If the setting is not
+// present or is not a valid duration, the default value is returned.
+func durationSetting(key string, defaultValue time.Duration) time.Duration {
+	if text := settings.Get(key); text != "" {
+		if d, err := time.ParseDuration(text); err == nil {
+			return d
+		}
+
+		ui.Log(ui.RestLogger, "Invalid duration %q for %s ignored", text, key)
+	}
+
+	return defaultValue
+}
@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+	"gopkg.in/resty.v1"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{name: "empty", value: "", ok: false},
+		{name: "seconds", value: "3", want: 3 * time.Second, ok: true},
+		{name: "past date", value: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0, ok: true},
+		{name: "invalid", value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.value)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RequestPolicy{Retries: 3, Delay: 100 * time.Millisecond, MaxDelay: time.Minute}
+
+	tests := []struct {
+		name       string
+		retryAfter string
+		want       time.Duration
+		ok         bool
+	}{
+		{name: "retry after", retryAfter: "30", want: 30 * time.Second, ok: true},
+		{name: "retry after at maximum", retryAfter: "60", want: time.Minute, ok: true},
+		{name: "retry after too long", retryAfter: "86400", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &resty.Response{RawResponse: &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{"Retry-After": []string{tt.retryAfter}},
+			}}
+
+			got, ok := policy.retryDelay(http.MethodGet, 0, resp, nil)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("retryDelay() = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RequestPolicy{Retries: 10, Delay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		ceiling := policy.Delay << uint(attempt)
+		if ceiling > policy.MaxDelay {
+			ceiling = policy.MaxDelay
+		}
+
+		if d := policy.backoff(attempt); d <= 0 || d > ceiling {
+			t.Errorf("backoff(%d) = %v, want (0, %v]", attempt, d, ceiling)
+		}
+	}
+}
+
+func TestExchangeRetry(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"status":200,"msg":"ok"}`))
+	}))
+	defer server.Close()
+
+	ctx := WithRequestPolicy(context.Background(), RequestPolicy{
+		Timeout:  time.Second,
+		Retries:  3,
+		Delay:    time.Millisecond,
+		MaxDelay: 10 * time.Millisecond,
+	})
+
+	response := defs.RestStatusResponse{}
+
+	if err := ServerExchangeWithContext(ctx, server.URL, defs.ServicesUpPath, http.MethodGet, nil, &response, defs.TableAgent); err != nil {
+		t.Errorf("ServerExchangeWithContext() error = %v", err)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("ServerExchangeWithContext() made %d calls, want 3", n)
+	}
+
+	// A POST is not idempotent, so it is not retried.
+	atomic.StoreInt32(&calls, 0)
+
+	_ = ServerExchangeWithContext(ctx, server.URL, defs.ServicesUpPath, http.MethodPost, nil, &response, defs.TableAgent)
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("ServerExchangeWithContext(POST) made %d calls, want 1", n)
+	}
+
+	// A cancelled context stops the request.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := ServerExchangeWithContext(cancelled, server.URL, defs.ServicesUpPath, http.MethodGet, nil, &response, defs.TableAgent); err == nil {
+		t.Errorf("ServerExchangeWithContext(cancelled) did not return an error")
+	}
+}
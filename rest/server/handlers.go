@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/tucats/gopackages/defs"
+)
+
+// HeartbeatHandler responds to defs.AdminHeartbeatPath with a 200 status and
+// no body, so clients can check that the server is running.
+func HeartbeatHandler(session *Session, w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// UpHandler responds to defs.ServicesUpPath with the version of the server,
+// its process ID, and the time it started.
+func (s *Server) UpHandler(session *Session, w http.ResponseWriter, r *http.Request) {
+	session.WriteJSON(w, http.StatusOK, defs.RemoteStatusResponse{
+		ServerInfo: session.ServerInfo(),
+		Version:    s.version,
+		Pid:        os.Getpid(),
+		Since:      s.started.Format(time.RFC1123),
+	})
+}
@@ -0,0 +1,345 @@
+// Package server is a small framework for REST servers that use the paths,
+// media types, and authentication levels defined in the defs package. It is
+// used to build test servers for the rest package, and small services.
+//
+// A server is created with New(), which registers the standard heartbeat and
+// status handlers. Other routes are added with Handle():
+//
+//	s := server.New("1.0")
+//	s.Handle(http.MethodGet, defs.TablesPath, listTables).
+//	    Requires(defs.UserAuthenticationRequired).
+//	    Accepts(defs.TablesMediaType)
+//	err := s.ListenAndServe(":8080")
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/util"
+)
+
+// APIVersion is the API version reported in the server information of each
+// response.
+const APIVersion = 1
+
+// HandlerFunc handles a request for a route. The session describes the
+// request and the authenticated user, if any.
+type HandlerFunc func(session *Session, w http.ResponseWriter, r *http.Request)
+
+// Route describes the requests sent to a handler. Use the methods of the route
+// returned by Server.Handle() to set the authentication required, and the
+// media types and parameters the route accepts.
+type Route struct {
+	method         string
+	path           string
+	handler        HandlerFunc
+	authentication string
+	mediaTypes     []string
+	parameters     map[string]string
+}
+
+// Server routes REST requests to their handlers. It implements http.Handler,
+// so it can be used with any http.Server or httptest.Server.
+type Server struct {
+	version       string
+	started       time.Time
+	routes        []*Route
+	sessions      int32
+	authenticator func(user, password string) bool
+	authorizer    func(user string) bool
+	mutex         sync.RWMutex
+}
+
+// New creates a server that reports the given version from its status
+// handler. The heartbeat and status handlers are already registered.
+func New(version string) *Server {
+	if defs.ServerInstanceID == "" {
+		defs.ServerInstanceID = uuid.New().String()
+	}
+
+	s := &Server{
+		version: version,
+		started: time.Now(),
+	}
+
+	s.Handle(http.MethodGet, defs.AdminHeartbeatPath, HeartbeatHandler)
+	s.Handle(http.MethodGet, defs.ServicesUpPath, s.UpHandler)
+
+	return s
+}
+
+// Handle registers the handler for requests with the method and path. An empty
+// method matches any method. A path that ends with "/" also matches any path
+// that starts with it, unless a longer route matches. The route requires no
+// authentication until Requires() is used.
+func (s *Server) Handle(method, path string, handler HandlerFunc) *Route {
+	route := &Route{
+		method:  strings.ToUpper(method),
+		path:    path,
+		handler: handler,
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.routes = append(s.routes, route)
+
+	// Keep the longest paths first, so the most specific prefix matches.
+	sort.SliceStable(s.routes, func(i, j int) bool {
+		return len(s.routes[i].path) > len(s.routes[j].path)
+	})
+
+	ui.Log(ui.ServerLogger, "Route added for %s %s", displayMethod(route.method), path)
+
+	return route
+}
+
+// SetAuthenticator sets the function that checks the user name and password
+// sent using basic authentication. Without one, only tokens are accepted.
+func (s *Server) SetAuthenticator(fn func(user, password string) bool) *Server {
+	s.authenticator = fn
+
+	return s
+}
+
+// SetAuthorizer sets the function that reports whether an authenticated user
+// is an administrator. Without one, no user is an administrator.
+func (s *Server) SetAuthorizer(fn func(user string) bool) *Server {
+	s.authorizer = fn
+
+	return s
+}
+
+// ListenAndServe accepts requests on the address until an error occurs.
+func (s *Server) ListenAndServe(address string) error {
+	ui.Log(ui.ServerLogger, "Listening on %s", address)
+
+	if err := http.ListenAndServe(address, s); err != nil {
+		return errors.NewError(err)
+	}
+
+	return nil
+}
+
+// ListenAndServeTLS accepts HTTPS requests on the address until an error
+// occurs, using the certificate and key files.
+func (s *Server) ListenAndServeTLS(address, certificateFile, keyFile string) error {
+	ui.Log(ui.ServerLogger, "Listening securely on %s", address)
+
+	if err := http.ListenAndServeTLS(address, certificateFile, keyFile, s); err != nil {
+		return errors.NewError(err)
+	}
+
+	return nil
+}
+
+// Requires sets the authentication required for the route. The level is one
+// of defs.UserAuthenticationRequired, defs.TokenRequired,
+// defs.AdminAuthneticationRequired, or defs.AdminTokenRequired. An empty level
+// means no authentication is required.
+func (r *Route) Requires(level string) *Route {
+	r.authentication = level
+
+	return r
+}
+
+// Accepts adds media types the route accepts in the Accept header, in addition
+// to the JSON and text types that are always accepted.
+func (r *Route) Accepts(mediaTypes ...string) *Route {
+	r.mediaTypes = append(r.mediaTypes, mediaTypes...)
+
+	return r
+}
+
+// Parameters sets the query parameters the route accepts, and their types, as
+// used by util.ValidateParameters(). A request with any other parameter is
+// rejected.
+func (r *Route) Parameters(parameters map[string]string) *Route {
+	r.parameters = parameters
+
+	return r
+}
+
+// ServeHTTP finds the route for a request, checks the request against it, and
+// calls its handler. Every request is logged with its status and duration.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	session := &Session{
+		ID:        int(atomic.AddInt32(&s.sessions, 1)),
+		MediaType: defs.JSONMediaType,
+		server:    s,
+	}
+
+	writer := &statusWriter{ResponseWriter: w}
+	start := time.Now()
+
+	ui.Log(ui.ServerLogger, "[%d] %s %s from %s", session.ID, r.Method, r.URL.RequestURI(), r.RemoteAddr)
+
+	s.serve(session, writer, r)
+
+	ui.LogWith(ui.ServerLogger, ui.Fields{
+		"status":  writer.Status(),
+		"user":    session.User,
+		"elapsed": time.Since(start).String(),
+	}, "[%d] %s %s completed", session.ID, r.Method, r.URL.Path)
+}
+
+func (s *Server) serve(session *Session, w http.ResponseWriter, r *http.Request) {
+	route, status := s.route(r.Method, r.URL.Path)
+	if route == nil {
+		err := errors.ErrNotFound.Context(r.URL.Path)
+		if status == http.StatusMethodNotAllowed {
+			err = errors.ErrMethodNotAllowed.Context(r.Method)
+		}
+
+		session.WriteError(w, status, err)
+
+		return
+	}
+
+	s.authenticate(session, r)
+
+	if status, err := session.authorize(route.authentication); err != nil {
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", challenge(route.authentication))
+		}
+
+		session.WriteError(w, status, err)
+
+		return
+	}
+
+	if err := util.AcceptedMediaType(r, route.mediaTypes); err != nil {
+		session.WriteError(w, http.StatusNotAcceptable, err)
+
+		return
+	}
+
+	session.MediaType = negotiate(r, route.mediaTypes)
+
+	if route.parameters != nil {
+		if err := util.ValidateParameters(r.URL, route.parameters); err != nil {
+			session.WriteError(w, http.StatusBadRequest, err)
+
+			return
+		}
+	}
+
+	route.handler(session, w, r)
+}
+
+// route finds the route for a method and path. If there is none, the status
+// says whether the path was not found, or was found for other methods.
+func (s *Server) route(method, path string) (*Route, int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	status := http.StatusNotFound
+
+	for _, route := range s.routes {
+		if !route.matches(path) {
+			continue
+		}
+
+		if route.method == "" || route.method == method {
+			return route, http.StatusOK
+		}
+
+		status = http.StatusMethodNotAllowed
+	}
+
+	return nil, status
+}
+
+// matches reports whether the route path matches a request path. The trailing
+// "/" of a route path is optional in the request.
+func (r *Route) matches(path string) bool {
+	if path == r.path || path+"/" == r.path {
+		return true
+	}
+
+	return strings.HasSuffix(r.path, "/") && strings.HasPrefix(path, r.path)
+}
+
+// negotiate selects the media type of the response. This is the first type in
+// the Accept header that the route accepts, or JSON if there isn't one.
+func negotiate(r *http.Request, mediaTypes []string) string {
+	for _, header := range r.Header.Values("Accept") {
+		for _, item := range strings.Split(header, ",") {
+			mediaType, _, _ := strings.Cut(item, ";")
+			mediaType = strings.TrimSpace(mediaType)
+
+			if util.InList(mediaType, mediaTypes...) {
+				return mediaType
+			}
+		}
+	}
+
+	return defs.JSONMediaType
+}
+
+// serverInfo returns the information about the server included in each
+// response.
+func (s *Server) serverInfo(session int) defs.ServerInfo {
+	return defs.ServerInfo{
+		Version:  APIVersion,
+		Hostname: util.Hostname(),
+		ID:       defs.ServerInstanceID,
+		Session:  session,
+	}
+}
+
+func displayMethod(method string) string {
+	if method == "" {
+		return "*"
+	}
+
+	return method
+}
+
+// statusWriter records the status written to a response, so it can be logged.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Status returns the status written to the response. If nothing was written,
+// the status is 200, as it is for the client.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+// Flush sends buffered data to the client, so handlers can stream responses.
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
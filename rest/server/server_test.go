@@ -0,0 +1,152 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/rest"
+)
+
+func TestServer(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("server-test", "default")
+
+	s := New("1.2.3").
+		SetAuthenticator(func(user, password string) bool {
+			return password == "secret"
+		}).
+		SetAuthorizer(func(user string) bool {
+			return user == "admin"
+		})
+
+	echo := func(session *Session, w http.ResponseWriter, r *http.Request) {
+		session.WriteJSON(w, http.StatusOK, map[string]interface{}{"user": session.User, "data": session.TokenData})
+	}
+
+	s.Handle(http.MethodGet, defs.TablesPath, echo).
+		Requires(defs.UserAuthenticationRequired).
+		Accepts(defs.TablesMediaType).
+		Parameters(map[string]string{defs.LimitParameterName: "int"})
+	s.Handle(http.MethodGet, defs.AdminUsersPath, echo).Requires(defs.AdminTokenRequired)
+	s.Handle("", defs.CodePath, echo).Requires(defs.TokenRequired)
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	userToken, err := s.CreateToken("joe", "payload")
+	if err != nil {
+		t.Fatalf("unexpected error creating token: %v", err)
+	}
+
+	adminToken, _ := s.CreateToken("admin", "")
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		token     string
+		user      string
+		password  string
+		accept    string
+		status    int
+		mediaType string
+	}{
+		{name: "heartbeat", method: http.MethodGet, path: defs.AdminHeartbeatPath, status: http.StatusOK},
+		{name: "unknown path", method: http.MethodGet, path: "/nowhere", status: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodPost, path: defs.AdminHeartbeatPath, status: http.StatusMethodNotAllowed},
+		{name: "no credentials", method: http.MethodGet, path: defs.TablesPath, status: http.StatusUnauthorized},
+		{name: "bad password", method: http.MethodGet, path: defs.TablesPath, user: "joe", password: "wrong", status: http.StatusUnauthorized},
+		{name: "basic auth", method: http.MethodGet, path: defs.TablesPath + "inventory", user: "joe", password: "secret", status: http.StatusOK, mediaType: defs.JSONMediaType},
+		{name: "token auth", method: http.MethodGet, path: defs.TablesPath, token: userToken, status: http.StatusOK},
+		{name: "invalid token", method: http.MethodGet, path: defs.TablesPath, token: "0badc0de", status: http.StatusUnauthorized},
+		{name: "media type", method: http.MethodGet, path: defs.TablesPath, token: userToken, accept: defs.TablesMediaType, status: http.StatusOK, mediaType: defs.TablesMediaType},
+		{name: "bad media type", method: http.MethodGet, path: defs.TablesPath, token: userToken, accept: defs.UsersMediaType, status: http.StatusNotAcceptable},
+		{name: "bad parameter", method: http.MethodGet, path: defs.TablesPath + "?limit=x", token: userToken, status: http.StatusBadRequest},
+		{name: "unknown parameter", method: http.MethodGet, path: defs.TablesPath + "?color=red", token: userToken, status: http.StatusBadRequest},
+		{name: "admin token", method: http.MethodGet, path: defs.AdminUsersPath, token: adminToken, status: http.StatusOK},
+		{name: "not admin", method: http.MethodGet, path: defs.AdminUsersPath, token: userToken, status: http.StatusForbidden},
+		{name: "admin needs token", method: http.MethodGet, path: defs.AdminUsersPath, user: "admin", password: "secret", status: http.StatusUnauthorized},
+		{name: "any method", method: http.MethodDelete, path: defs.CodePath, token: userToken, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+
+			if tt.mediaType != "" && resp.Header.Get("Content-Type") != tt.mediaType {
+				t.Errorf("media type = %q, want %q", resp.Header.Get("Content-Type"), tt.mediaType)
+			}
+		})
+	}
+}
+
+func TestServerWithClient(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("server-test", "default")
+
+	s := New("1.2.3")
+	s.Handle(http.MethodGet, defs.TablesPath, func(session *Session, w http.ResponseWriter, r *http.Request) {
+		session.WriteJSON(w, http.StatusOK, map[string]interface{}{"user": session.User, "data": session.TokenData})
+	}).Requires(defs.TokenRequired)
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	status := defs.RemoteStatusResponse{}
+	if err := rest.NewClient(ts.URL).Exchange(defs.ServicesUpPath, http.MethodGet, nil, &status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if status.Version != "1.2.3" || status.ID != defs.ServerInstanceID || status.Pid == 0 {
+		t.Errorf("unexpected status response: %+v", status)
+	}
+
+	err := rest.NewClient(ts.URL).Exchange(defs.TablesPath, http.MethodGet, nil, nil)
+	if rest.StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %v", err)
+	}
+
+	token, _ := s.CreateToken("joe", "payload")
+	result := map[string]interface{}{}
+
+	err = rest.NewClient(ts.URL).
+		SetCredential(rest.Credential{Token: token, Method: rest.TokenAuthentication}).
+		Exchange(defs.TablesPath, http.MethodGet, nil, &result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result["user"] != "joe" || result["data"] != "payload" {
+		t.Errorf("unexpected response: %v", result)
+	}
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"github.com/tucats/gopackages/expressions/functions"
+	"github.com/tucats/gopackages/expressions/symbols"
+	"github.com/tucats/gopackages/util"
+)
+
+// Session describes a request being handled by a server.
+type Session struct {
+	// The number of the request, counted from the time the server started.
+	ID int
+
+	// The name of the authenticated user, or an empty string.
+	User string
+
+	// True if the user was authenticated, either with a token or with a
+	// user name and password.
+	Authenticated bool
+
+	// True if the user was authenticated with a token.
+	TokenAuthenticated bool
+
+	// True if the authenticated user is an administrator.
+	Admin bool
+
+	// The data stored in the token, if the user was authenticated with one.
+	TokenData string
+
+	// The media type of the response, selected from the Accept header of the
+	// request and the media types accepted by the route.
+	MediaType string
+
+	server *Server
+}
+
+// ServerInfo returns the information about the server included in each
+// response.
+func (s *Session) ServerInfo() defs.ServerInfo {
+	return s.server.serverInfo(s.ID)
+}
+
+// WriteJSON writes the value as the JSON body of the response, with the status
+// and the negotiated media type.
+func (s *Session) WriteJSON(w http.ResponseWriter, status int, value interface{}) {
+	b, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		s.WriteError(w, http.StatusInternalServerError, errors.NewError(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", s.MediaType)
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+// WriteError writes an error response with the status. The body is a status
+// response, so clients can report the message, and match the error by its
+// code.
+func (s *Session) WriteError(w http.ResponseWriter, status int, err error) {
+	response := defs.RestStatusResponse{
+		ServerInfo: s.ServerInfo(),
+		Status:     status,
+	}
+
+	if err != nil {
+		response.Message = err.Error()
+		response.Error, _ = json.Marshal(errors.NewError(err))
+	}
+
+	ui.Log(ui.ServerLogger, "[%d] Error response, %s", s.ID, response.Message)
+
+	b, _ := json.MarshalIndent(response, "", "  ")
+
+	w.Header().Set("Content-Type", defs.JSONMediaType)
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+// CreateToken creates a token for the user, using the same functions as the
+// cipher package. The data is stored in the token, and is available in the
+// session of a request that uses it. The token expires after the interval in
+// the "token-expiration" setting.
+func (s *Server) CreateToken(user, data string) (string, error) {
+	token, err := functions.CreateToken(symbols.NewSymbolTable("server"), []interface{}{user, data})
+	if err != nil {
+		return "", errors.NewError(err)
+	}
+
+	return util.GetString(token), nil
+}
+
+// authenticate checks the credentials in the Authorization header of the
+// request, and records the user in the session if they are valid. Invalid
+// credentials are treated the same as no credentials.
+func (s *Server) authenticate(session *Session, r *http.Request) {
+	scheme, credentials, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+
+	switch strings.ToLower(scheme) {
+	case "":
+		return
+
+	case "bearer", "token":
+		info, err := functions.Extract(symbols.NewSymbolTable("server"), []interface{}{strings.TrimSpace(credentials)})
+		if err != nil {
+			ui.Log(ui.AuthLogger, "[%d] Invalid token, %v", session.ID, err)
+
+			return
+		}
+
+		fields, _ := info.(map[string]interface{})
+		session.User = util.GetString(fields["name"])
+		session.TokenData = util.GetString(fields["data"])
+		session.TokenAuthenticated = true
+
+	case "basic":
+		user, password, ok := r.BasicAuth()
+		if !ok || s.authenticator == nil || !s.authenticator(user, password) {
+			ui.Log(ui.AuthLogger, "[%d] Invalid credentials for user %q", session.ID, user)
+
+			return
+		}
+
+		session.User = user
+
+	default:
+		ui.Log(ui.AuthLogger, "[%d] Unsupported authorization scheme %q", session.ID, scheme)
+
+		return
+	}
+
+	session.Authenticated = true
+
+	if s.authorizer != nil {
+		session.Admin = s.authorizer(session.User)
+	}
+
+	ui.Log(ui.AuthLogger, "[%d] Authenticated user %q, admin %v", session.ID, session.User, session.Admin)
+}
+
+// authorize checks that the session has the authentication required by a
+// route. If it does not, the status and error for the response are returned.
+func (s *Session) authorize(level string) (int, error) {
+	switch level {
+	case "":
+		return http.StatusOK, nil
+
+	case defs.UserAuthenticationRequired, defs.AdminAuthneticationRequired:
+		if !s.Authenticated {
+			return http.StatusUnauthorized, errors.ErrNoCredentials
+		}
+
+	case defs.TokenRequired, defs.AdminTokenRequired:
+		if !s.TokenAuthenticated {
+			return http.StatusUnauthorized, errors.ErrNoCredentials
+		}
+
+	default:
+		return http.StatusInternalServerError, errors.ErrInvalidAuthenticationType.Context(level)
+	}
+
+	if (level == defs.AdminAuthneticationRequired || level == defs.AdminTokenRequired) && !s.Admin {
+		return http.StatusForbidden, errors.ErrNoPrivilegeForOperation.Context(s.User)
+	}
+
+	return http.StatusOK, nil
+}
+
+// challenge returns the WWW-Authenticate header sent when a request for a
+// route was not authenticated.
+func challenge(level string) string {
+	if level == defs.TokenRequired || level == defs.AdminTokenRequired {
+		return "Bearer"
+	}
+
+	return `Basic realm="` + util.Hostname() + `", charset="UTF-8"`
+}
@@ -0,0 +1,261 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"gopkg.in/resty.v1"
+)
+
+// The most of an error response body that is read from a streamed response.
+const maxErrorBodySize = 64 * 1024
+
+// ProgressFunc is called as the body of a request or response is transferred.
+// The total is the expected size in bytes, or -1 if the size is not known.
+type ProgressFunc func(transferred, total int64)
+
+// UploadFile describes a file sent as part of a multipart upload.
+type UploadFile struct {
+	// The name of the form field for the file.
+	Field string
+
+	// The file name sent to the server.
+	Name string
+
+	// The contents of the file.
+	Reader io.Reader
+}
+
+// progressReader reports the progress of reading from a reader.
+type progressReader struct {
+	reader      io.Reader
+	total       int64
+	transferred int64
+	progress    ProgressFunc
+}
+
+// NewProgressReader returns a reader that calls the progress function as the
+// reader is read. This is used to report the progress of an upload, by using
+// it as the request body. The total is the expected size, or -1 if it is not
+// known. A request using the result as its body can only be retried if the
+// reader is also an io.Seeker.
+func NewProgressReader(reader io.Reader, total int64, progress ProgressFunc) io.Reader {
+	return &progressReader{reader: reader, total: total, progress: progress}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	if n > 0 {
+		p.transferred += int64(n)
+
+		if p.progress != nil {
+			p.progress(p.transferred, p.total)
+		}
+	}
+
+	return n, err
+}
+
+// Seek rewinds the underlying reader, so a request can be retried. It fails
+// if the underlying reader is not an io.Seeker.
+func (p *progressReader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := p.reader.(io.Seeker)
+	if !ok {
+		return 0, errors.ErrInvalidType.Context("io.Seeker")
+	}
+
+	position, err := seeker.Seek(offset, whence)
+	if err == nil {
+		p.transferred = position
+	}
+
+	return position, err
+}
+
+// progressWriter reports the progress of writing to a writer.
+type progressWriter struct {
+	writer      io.Writer
+	total       int64
+	transferred int64
+	progress    ProgressFunc
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.writer.Write(b)
+	p.transferred += int64(n)
+
+	if p.progress != nil {
+		p.progress(p.transferred, p.total)
+	}
+
+	return n, err
+}
+
+// Download sends a GET request to the endpoint, and copies the response body
+// to the writer as it is received, without holding it in memory. If the
+// progress function is not nil, it is called as the body is written. The
+// number of bytes written is returned.
+func (c *Client) Download(ctx context.Context, endpoint string, w io.Writer, progress ProgressFunc, mediaTypes ...string) (int64, error) {
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{defs.BinaryMediaType}
+	}
+
+	body, total, err := c.stream(ctx, request{
+		endpoint:   endpoint,
+		method:     http.MethodGet,
+		agent:      c.agent,
+		mediaTypes: mediaTypes,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	defer body.Close()
+
+	n, err := io.Copy(&progressWriter{writer: w, total: total, progress: progress}, body)
+	if err != nil {
+		return n, errors.NewError(err)
+	}
+
+	ui.Log(c.logger, "Downloaded %d bytes", n)
+
+	return n, nil
+}
+
+// Upload sends a multipart form to the endpoint using a POST request. The
+// fields are sent as form values, followed by the files. The files are read
+// as the request is sent, so they are not held in memory. Because the body
+// cannot be read again, the request is not retried. If the response object
+// is not nil, the reply is decoded into it. To report the progress of the
+// upload, use NewProgressReader() for the file readers.
+func (c *Client) Upload(ctx context.Context, endpoint string, fields map[string]string, files []UploadFile, response interface{}) error {
+	pipeReader, pipeWriter := io.Pipe()
+	form := multipart.NewWriter(pipeWriter)
+
+	// The form is written by a separate goroutine, as the request reads it.
+	go func() {
+		pipeWriter.CloseWithError(writeMultipart(form, fields, files))
+	}()
+
+	resp, err := c.send(ctx, request{
+		endpoint:   endpoint,
+		method:     http.MethodPost,
+		body:       pipeReader,
+		agent:      c.agent,
+		mediaTypes: []string{defs.JSONMediaType, form.FormDataContentType()},
+	})
+
+	// If the request failed before the body was read, stop the writer.
+	_ = pipeReader.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return c.response(resp, response)
+}
+
+// writeMultipart writes the fields and files of a multipart form.
+func writeMultipart(form *multipart.Writer, fields map[string]string, files []UploadFile) error {
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		part, err := form.CreateFormFile(file.Field, file.Name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(part, file.Reader); err != nil {
+			return err
+		}
+	}
+
+	return form.Close()
+}
+
+// StreamJSON sends a request to the endpoint, and decodes the response as a
+// stream of newline-delimited JSON objects. The function is called for each
+// object as it is received, so the whole response is never held in memory. If
+// the function returns an error, the rest of the response is discarded and the
+// error is returned.
+func (c *Client) StreamJSON(ctx context.Context, endpoint, method string, body interface{}, fn func(item map[string]interface{}) error) error {
+	reader, _, err := c.stream(ctx, request{
+		endpoint:   endpoint,
+		method:     method,
+		body:       body,
+		agent:      c.agent,
+		mediaTypes: []string{defs.NDJSONMediaType},
+	})
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	decoder := json.NewDecoder(reader)
+	count := 0
+
+	for {
+		item := map[string]interface{}{}
+
+		if err := decoder.Decode(&item); err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.NewError(err)
+		}
+
+		count++
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	ui.Log(c.logger, "Decoded %d streamed items", count)
+
+	return nil
+}
+
+// stream sends a request whose response body is read by the caller. The
+// caller must close the body. The expected size of the body is returned, or
+// -1 if it is not known. If the response status is not in the 2xx range, the
+// body is closed and an HTTPError is returned.
+func (c *Client) stream(ctx context.Context, req request) (io.ReadCloser, int64, error) {
+	req.stream = true
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	status := resp.StatusCode()
+
+	ui.Log(c.logger, "Status: %d", status)
+
+	if status < 200 || status > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.RawBody(), maxErrorBodySize))
+		closeRawBody(resp)
+
+		return nil, 0, errors.NewError(newHTTPError(resp, body))
+	}
+
+	return resp.RawBody(), resp.RawResponse.ContentLength, nil
+}
+
+// closeRawBody closes the body of a streamed response. If the response was not
+// streamed, resty has already closed the body, and closing it again is harmless.
+func closeRawBody(resp *resty.Response) {
+	if resp != nil && resp.RawResponse != nil && resp.RawResponse.Body != nil {
+		_ = resp.RawResponse.Body.Close()
+	}
+}
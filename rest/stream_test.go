@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+)
+
+func TestStreaming(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	payload := strings.Repeat("0123456789", 10000)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download":
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+			_, _ = w.Write([]byte(payload))
+
+		case "/upload":
+			if err := r.ParseMultipartForm(1024); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			file, _, _ := r.FormFile("artifact")
+			b, _ := io.ReadAll(file)
+
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": r.FormValue("name"), "size": len(b)})
+
+		case "/echo":
+			b, _ := io.ReadAll(r.Body)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"size": len(b), "type": r.Header.Get("Content-Type")})
+
+		case "/rows":
+			w.Header().Set("Content-Type", defs.NDJSONMediaType)
+
+			for n := 0; n < 3; n++ {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"row": n})
+			}
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	ctx := context.Background()
+
+	t.Run("download", func(t *testing.T) {
+		var out bytes.Buffer
+
+		var lastProgress, lastTotal int64
+
+		n, err := client.Download(ctx, "/download", &out, func(transferred, total int64) {
+			lastProgress, lastTotal = transferred, total
+		})
+		if err != nil || n != int64(len(payload)) || out.String() != payload {
+			t.Fatalf("Download() = %d, %v", n, err)
+		}
+
+		if lastProgress != n || lastTotal != n {
+			t.Errorf("Download() progress = %d of %d, want %d", lastProgress, lastTotal, n)
+		}
+
+		if _, err := client.Download(ctx, "/missing", &out, nil); StatusCode(err) != http.StatusNotFound {
+			t.Errorf("Download(missing) error = %v", err)
+		}
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		response := map[string]interface{}{}
+
+		err := client.Upload(ctx, "/upload", map[string]string{"name": "build"}, []UploadFile{
+			{Field: "artifact", Name: "build.zip", Reader: strings.NewReader(payload)},
+		}, &response)
+		if err != nil || response["name"] != "build" || response["size"] != float64(len(payload)) {
+			t.Errorf("Upload() = %v, %v", response, err)
+		}
+	})
+
+	t.Run("reader body", func(t *testing.T) {
+		var sent int64
+
+		body := NewProgressReader(strings.NewReader(payload), int64(len(payload)), func(transferred, total int64) {
+			sent = transferred
+		})
+
+		response := map[string]interface{}{}
+
+		err := client.ExchangeWithContext(ctx, "/echo", http.MethodPut, body, &response, defs.JSONMediaType, defs.BinaryMediaType)
+		if err != nil || response["size"] != float64(len(payload)) || response["type"] != defs.BinaryMediaType {
+			t.Errorf("ExchangeWithContext(reader) = %v, %v", response, err)
+		}
+
+		if sent != int64(len(payload)) {
+			t.Errorf("ExchangeWithContext(reader) progress = %d, want %d", sent, len(payload))
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		rows := []float64{}
+
+		err := client.StreamJSON(ctx, "/rows", http.MethodGet, nil, func(item map[string]interface{}) error {
+			rows = append(rows, item["row"].(float64))
+
+			return nil
+		})
+		if err != nil || len(rows) != 3 || rows[2] != 2 {
+			t.Errorf("StreamJSON() = %v, %v", rows, err)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/app-cli/ui"
+	"github.com/tucats/gopackages/defs"
+	"github.com/tucats/gopackages/errors"
+	"gopkg.in/resty.v1"
+)
+
+// newRestClient creates a resty client whose transport uses the TLS and proxy
+// configuration from the profile. If the TLS configuration is nil, a copy of
+// the configuration from GetTLSConfiguration() is used.
+func newRestClient(config *tls.Config) (*resty.Client, error) {
+	if config == nil {
+		shared, err := GetTLSConfiguration()
+		if err != nil {
+			return nil, err
+		}
+
+		// The configuration is copied, since authentication may add a client
+		// certificate to it.
+		config = shared.Clone()
+	}
+
+	proxy, err := proxyFunction()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	transport.Proxy = proxy
+
+	return resty.New().SetTransport(transport), nil
+}
+
+// wrappingTransport is a transport, such as a cassette or a cache, that
+// sends requests using another transport.
+type wrappingTransport interface {
+	unwrap() http.RoundTripper
+}
+
+// baseTransport returns the transport that makes the connections for a
+// transport that may be wrapped by a cassette or a cache. If it is not an
+// *http.Transport, nil is returned.
+func baseTransport(transport http.RoundTripper) *http.Transport {
+	for {
+		switch t := transport.(type) {
+		case *http.Transport:
+			return t
+
+		case wrappingTransport:
+			transport = t.unwrap()
+
+		default:
+			return nil
+		}
+	}
+}
+
+// proxyFunction returns the function that selects the proxy for a request.
+// If there is no proxy in the profile, the proxy environment variables are
+// used.
+func proxyFunction() (func(*http.Request) (*url.URL, error), error) {
+	text := settings.Get(defs.RestProxySetting)
+	if text == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxy, err := url.Parse(text)
+	if err != nil || proxy.Host == "" {
+		return nil, errors.ErrInvalidURL.Context(text)
+	}
+
+	switch strings.ToLower(proxy.Scheme) {
+	case "http", "https", "socks5":
+	default:
+		return nil, errors.ErrInvalidURL.Context(text)
+	}
+
+	noProxy := []string{}
+
+	for _, entry := range strings.Split(settings.Get(defs.RestNoProxySetting), ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			noProxy = append(noProxy, entry)
+		}
+	}
+
+	ui.Log(ui.RestLogger, "Using proxy %s", proxy.Redacted())
+
+	return func(r *http.Request) (*url.URL, error) {
+		if bypassProxy(r.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+
+		return proxy, nil
+	}, nil
+}
+
+// bypassProxy reports whether a host matches an entry in the no-proxy list. An
+// entry matches the host itself, or any host in the domain if the entry starts
+// with ".". An IP address also matches a CIDR network entry.
+func bypassProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+
+	for _, entry := range noProxy {
+		switch {
+		case entry == "*":
+			return true
+
+		case strings.Contains(entry, "/"):
+			if _, network, err := net.ParseCIDR(entry); err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+
+		case strings.HasPrefix(entry, "."):
+			if strings.HasSuffix(host, entry) || host == entry[1:] {
+				return true
+			}
+
+		default:
+			if h, _, err := net.SplitHostPort(entry); err == nil {
+				entry = h
+			}
+
+			if host == entry || strings.HasSuffix(host, "."+entry) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
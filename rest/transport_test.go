@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tucats/gopackages/app-cli/settings"
+	"github.com/tucats/gopackages/defs"
+)
+
+func TestBypassProxy(t *testing.T) {
+	noProxy := []string{"localhost", ".internal.example.com", "10.0.0.0/8", "build.example.org:8080"}
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "localhost", want: true},
+		{host: "api.internal.example.com", want: true},
+		{host: "internal.example.com", want: true},
+		{host: "example.com", want: false},
+		{host: "10.1.2.3", want: true},
+		{host: "192.168.1.1", want: false},
+		{host: "build.example.org", want: true},
+		{host: "ci.build.example.org", want: true},
+		{host: "notbuild.example.org", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := bypassProxy(tt.host, noProxy); got != tt.want {
+				t.Errorf("bypassProxy(%s) = %v, want %v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransportConfiguration(t *testing.T) {
+	previous := settings.SetStore(settings.NewMemoryStore())
+	defer settings.SetStore(previous)
+
+	_ = settings.Load("rest-test", "default")
+
+	AllowInsecure(true)
+
+	keys := []string{defs.TLSMinVersionSetting, defs.TLSServerNameSetting, defs.RestProxySetting, defs.RestNoProxySetting}
+
+	defer func() {
+		for _, key := range keys {
+			_ = settings.Delete(key)
+		}
+
+		ResetTLSConfiguration()
+	}()
+
+	settings.Set(defs.TLSMinVersionSetting, "1.2")
+	settings.Set(defs.TLSServerNameSetting, "api.example.com")
+	ResetTLSConfiguration()
+
+	config, err := GetTLSConfiguration()
+	if err != nil || config.MinVersion != tls.VersionTLS12 || config.ServerName != "api.example.com" {
+		t.Errorf("GetTLSConfiguration() = %v, %v", config, err)
+	}
+
+	settings.Set(defs.TLSMinVersionSetting, "2.0")
+	ResetTLSConfiguration()
+
+	if _, err := GetTLSConfiguration(); err == nil {
+		t.Errorf("GetTLSConfiguration() did not reject invalid version")
+	}
+
+	settings.Set(defs.TLSMinVersionSetting, "")
+	ResetTLSConfiguration()
+
+	// Requests for hosts not in the no-proxy list are sent to the proxy.
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"proxied":"` + r.Host + `"}`))
+	}))
+	defer proxy.Close()
+
+	settings.Set(defs.RestProxySetting, proxy.URL)
+	settings.Set(defs.RestNoProxySetting, ".internal")
+
+	response := map[string]interface{}{}
+	if err := NewClient("http://api.example.invalid").Exchange("/up", http.MethodGet, nil, &response); err != nil || response["proxied"] != "api.example.invalid" {
+		t.Errorf("Exchange() through proxy = %v, %v", response, err)
+	}
+
+	settings.Set(defs.RestProxySetting, "ftp://proxy")
+
+	if _, err := proxyFunction(); err == nil {
+		t.Errorf("proxyFunction() did not reject invalid scheme")
+	}
+}